@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"koalbot_api/internal/config"
+)
+
+const usage = `usage:
+  api                 start the HTTP server
+  api config print    print the effective configuration with secrets masked
+  api config check    validate the configuration and exit`
+
+func runCommand(args []string) int {
+	if len(args) != 2 || args[0] != "config" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		return 1
+	}
+
+	switch args[1] {
+	case "print":
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "check":
+		fmt.Println("configuration ok")
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	return 0
+}
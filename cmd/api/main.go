@@ -13,16 +13,42 @@ import (
 	"koalbot_api/internal/config"
 	"koalbot_api/internal/db"
 	"koalbot_api/internal/handler"
+	"koalbot_api/internal/logging"
 	"koalbot_api/internal/migrate"
+	"koalbot_api/internal/payment"
+	"koalbot_api/internal/presence"
+	"koalbot_api/internal/probe"
 	"koalbot_api/internal/repository"
+	"koalbot_api/internal/retention"
 	"koalbot_api/internal/router"
 	"koalbot_api/internal/seed"
 	"koalbot_api/internal/service"
 	"koalbot_api/internal/stockity"
+	"koalbot_api/internal/tracing"
 )
 
 func main() {
-	cfg := config.Load()
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	logging.SetLevel(level)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		OTLPInsecure: cfg.TracingOTLPInsecure,
+		ServiceName:  cfg.TracingServiceName,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	database, err := db.Open(cfg.DBDSN, cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBConnMaxLifetime)
 	if err != nil {
@@ -30,7 +56,8 @@ func main() {
 	}
 	defer database.Close()
 
-	if err := migrate.Run(database, cfg.MigrationPath); err != nil {
+	migration, err := migrate.Run(database, cfg.MigrationPath)
+	if err != nil {
 		log.Fatal(err)
 	}
 
@@ -38,32 +65,117 @@ func main() {
 	masterPenggunaRepo := repository.NewMasterPenggunaRepository(database)
 	penggunaDetailRepo := repository.NewPenggunaDetailRepository(database)
 	tokenRepo := repository.NewTokenRepository(database)
+	dashboardRepo := repository.NewDashboardRepository(database)
+	penggunaLoginRepo := repository.NewPenggunaLoginRepository(database)
+	penggunaDeviceRepo := repository.NewPenggunaDeviceRepository(database)
+	penggunaPresenceRepo := repository.NewPenggunaPresenceRepository(database)
+	penggunaCommandRepo := repository.NewPenggunaCommandRepository(database)
+	botConfigRepo := repository.NewBotConfigRepository(database)
+	clientVersionRepo := repository.NewClientVersionRepository(database)
+	penggunaTradeRepo := repository.NewPenggunaTradeRepository(database)
+	reportRepo := repository.NewReportRepository(database)
+	balanceHistoryRepo := repository.NewBalanceHistoryRepository(database)
+	riskRepo := repository.NewRiskRepository(database)
+	webhookRepo := repository.NewWebhookRepository(database)
+	paymentRepo := repository.NewPaymentRepository(database)
 	authService := service.NewAuthService(userRepo)
 	userService := service.NewUserService(userRepo)
 	masterPenggunaService := service.NewMasterPenggunaService(masterPenggunaRepo)
 	tokenService := service.NewTokenService(cfg.JWTSecret, tokenRepo)
-	stockityClient := stockity.NewClient(cfg.StockityBaseURL, 15*time.Second)
+	dashboardService := service.NewDashboardService(dashboardRepo)
+	loginAuditService := service.NewLoginAuditService(penggunaLoginRepo, cfg.LoginAlertDevices, cfg.LoginAlertWindow)
+	deviceService := service.NewDeviceService(penggunaDeviceRepo, cfg.MaxDevices, cfg.DeviceLimitPolicy, cfg.DeviceIdle)
+	sessionService := service.NewPenggunaSessionService(masterPenggunaRepo, penggunaDeviceRepo)
+	commandService := service.NewCommandService(penggunaCommandRepo)
+	botConfigService := service.NewBotConfigService(botConfigRepo)
+	clientVersionService := service.NewClientVersionService(clientVersionRepo)
+	riskService := service.NewRiskService(riskRepo, penggunaLoginRepo)
+	tradeService := service.NewTradeService(penggunaTradeRepo, riskService)
+	reportService := service.NewReportService(reportRepo)
+	balanceHistoryService := service.NewBalanceHistoryService(balanceHistoryRepo)
+	webhookService := service.NewWebhookService(webhookRepo, cfg.WebhookTimeout, cfg.WebhookMaxAttempts, cfg.WebhookRetryBase)
+	paymentService := service.NewPaymentService(paymentRepo, cfg.PaymentPeriodDays, paymentProviders(cfg)...)
+	presenceRegistry := presence.New(penggunaPresenceRepo, cfg.PresenceTTL, cfg.PresencePersistEvery)
+	stockityClient := stockity.NewClient(cfg.StockityBaseURL, cfg.StockityTimeout)
 	authHandler := handler.NewAuthHandler(authService, tokenService)
 	userHandler := handler.NewUserHandler(userService)
 	masterPenggunaHandler := handler.NewMasterPenggunaHandler(masterPenggunaService)
-	v1LoginHandler := handler.NewV1LoginHandler(stockityClient, masterPenggunaRepo, penggunaDetailRepo, cfg.JWTSecret, cfg.StockityBaseURL)
+	dashboardHandler := handler.NewDashboardHandler(dashboardService)
+	penggunaLoginHandler := handler.NewPenggunaLoginHandler(loginAuditService)
+	penggunaDeviceHandler := handler.NewPenggunaDeviceHandler(deviceService)
+	presenceHandler := handler.NewPresenceHandler(presenceRegistry, penggunaPresenceRepo)
+	v1HeartbeatHandler := handler.NewV1HeartbeatHandler(presenceRegistry, riskService)
+	penggunaCommandHandler := handler.NewPenggunaCommandHandler(commandService)
+	v1CommandHandler := handler.NewV1CommandHandler(commandService)
+	botConfigHandler := handler.NewBotConfigHandler(botConfigService)
+	v1ConfigHandler := handler.NewV1ConfigHandler(botConfigService, riskService)
+	clientVersionHandler := handler.NewClientVersionHandler(clientVersionService)
+	tradeHandler := handler.NewTradeHandler(tradeService)
+	v1TradeHandler := handler.NewV1TradeHandler(tradeService)
+	reportHandler := handler.NewReportHandler(reportService)
+	balanceHistoryHandler := handler.NewBalanceHistoryHandler(balanceHistoryService)
+	riskHandler := handler.NewRiskHandler(riskService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	v1LoginHandler := handler.NewV1LoginHandler(stockityClient, masterPenggunaRepo, penggunaDetailRepo, loginAuditService, deviceService, clientVersionService, riskService, cfg.JWTSecret)
 	streamHandler := handler.NewStreamHandler()
-	healthHandler := handler.NewHealthHandler(database)
+	stockityProbe := probe.New(stockityClient.Ping, cfg.StockityProbeEvery, 5*time.Second)
+	healthHandler := handler.NewHealthHandler(database, migration, stockityProbe)
+
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	defer bgCancel()
+	go stockityProbe.Run(bgCtx)
+	presenceDone := make(chan struct{})
+	go func() {
+		presenceRegistry.Run(bgCtx)
+		close(presenceDone)
+	}()
+	go commandService.Run(bgCtx)
+	go masterPenggunaService.RunExpiry(bgCtx)
+	go webhookService.Run(bgCtx)
+
+	if cfg.PurgeAfter > 0 {
+		go retention.NewJob("m_pengguna", masterPenggunaRepo, cfg.PurgeAfter, cfg.PurgeInterval).Run(bgCtx)
+		go retention.NewJob("m_user", userRepo, cfg.PurgeAfter, cfg.PurgeInterval).Run(bgCtx)
+	}
 
 	if err := seed.Users(database); err != nil {
 		log.Fatal(err)
 	}
 
-	r := router.New(authHandler, v1LoginHandler, userHandler, masterPenggunaHandler, streamHandler, healthHandler, router.Options{
-		MaxBodyBytes:         cfg.MaxBodyBytes,
-		RateLimitRPS:         cfg.RateLimitRPS,
-		RateLimitBurst:       cfg.RateLimitBurst,
-		LoginRateLimitRPS:    cfg.LoginRateLimitRPS,
-		LoginRateLimitBurst:  cfg.LoginRateLimitBurst,
-		TrustedProxies:       cfg.TrustedProxies,
-		JWTSecret:            cfg.JWTSecret,
-		CORSAllowedOrigins:   cfg.CORSAllowedOrigins,
-		CORSAllowCredentials: cfg.CORSAllowCredentials,
+	r, runtime := router.New(router.Handlers{
+		Auth:            authHandler,
+		V1Login:         v1LoginHandler,
+		V1Heartbeat:     v1HeartbeatHandler,
+		V1Commands:      v1CommandHandler,
+		V1Config:        v1ConfigHandler,
+		V1Trades:        v1TradeHandler,
+		Users:           userHandler,
+		MasterPengguna:  masterPenggunaHandler,
+		PenggunaLogins:  penggunaLoginHandler,
+		PenggunaDevices: penggunaDeviceHandler,
+		Presence:        presenceHandler,
+		Commands:        penggunaCommandHandler,
+		BotConfig:       botConfigHandler,
+		ClientVersions:  clientVersionHandler,
+		Trades:          tradeHandler,
+		Reports:         reportHandler,
+		BalanceHistory:  balanceHistoryHandler,
+		Risk:            riskHandler,
+		Webhooks:        webhookHandler,
+		Payments:        paymentHandler,
+		Dashboard:       dashboardHandler,
+		Stream:          streamHandler,
+		Health:          healthHandler,
+	}, sessionService, tokenService, routerOptions(cfg))
+
+	watchReload(cfg, func(next config.Config) {
+		level, _ := logging.ParseLevel(next.LogLevel)
+		logging.SetLevel(level)
+		runtime.Apply(routerOptions(next))
+		stockityClient.SetBaseURL(next.StockityBaseURL)
+		stockityClient.SetTimeout(next.StockityTimeout)
+		deviceService.SetLimit(next.MaxDevices, next.DeviceLimitPolicy, next.DeviceIdle)
 	})
 
 	httpSrv := &http.Server{
@@ -76,7 +188,7 @@ func main() {
 	}
 
 	go func() {
-		log.Printf("listening on :%s", cfg.Port)
+		logging.Infof("listening on :%s", cfg.Port)
 		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatal(err)
 		}
@@ -86,9 +198,41 @@ func main() {
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
+	healthHandler.SetShuttingDown()
+	logging.Infof("shutting down, draining for %s", cfg.ShutdownDrainDelay)
+	time.Sleep(cfg.ShutdownDrainDelay)
+
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("shutdown error: %v", err)
+		logging.Errorf("shutdown error: %v", err)
+	}
+	bgCancel()
+	<-presenceDone
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logging.Errorf("tracing shutdown error: %v", err)
+	}
+}
+
+func routerOptions(cfg config.Config) router.Options {
+	return router.Options{
+		MaxBodyBytes:         cfg.MaxBodyBytes,
+		RateLimitRPS:         cfg.RateLimitRPS,
+		RateLimitBurst:       cfg.RateLimitBurst,
+		LoginRateLimitRPS:    cfg.LoginRateLimitRPS,
+		LoginRateLimitBurst:  cfg.LoginRateLimitBurst,
+		TrustedProxies:       cfg.TrustedProxies,
+		JWTSecret:            cfg.JWTSecret,
+		CORSAllowedOrigins:   cfg.CORSAllowedOrigins,
+		CORSAllowCredentials: cfg.CORSAllowCredentials,
+		TracingServiceName:   cfg.TracingServiceName,
+	}
+}
+
+func paymentProviders(cfg config.Config) []payment.Provider {
+	var providers []payment.Provider
+	if cfg.MidtransServerKey != "" {
+		providers = append(providers, payment.NewMidtrans(cfg.MidtransServerKey))
 	}
+	return providers
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"koalbot_api/internal/config"
+	"koalbot_api/internal/logging"
+)
+
+func watchReload(current config.Config, apply func(config.Config)) {
+	for _, key := range config.EnvOverrides() {
+		logging.Warnf("config reload: %s is set in the environment, which SIGHUP cannot change; set it in CONFIG_FILE to reload it", key)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		for range hup {
+			next, err := config.Load()
+			if err != nil {
+				logging.Errorf("config reload rejected:\n%v", err)
+				continue
+			}
+
+			merged, applied, skipped := config.Reload(current, next)
+			for _, change := range applied {
+				logging.Infof("config reload: %s %q -> %q", change.Key, change.Old, change.New)
+			}
+			for _, change := range skipped {
+				logging.Warnf("config reload: %s changed but requires a restart, ignored", change.Key)
+			}
+			if len(applied) == 0 {
+				logging.Infof("config reload: no reloadable changes")
+				continue
+			}
+
+			apply(merged)
+			current = merged
+		}
+	}()
+}
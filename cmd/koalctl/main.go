@@ -0,0 +1,386 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"koalbot_api/internal/config"
+	"koalbot_api/internal/db"
+	"koalbot_api/internal/pagination"
+	"koalbot_api/internal/payment"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+const actor = "koalctl"
+
+const usage = `usage: koalctl <command> [flags]
+
+commands:
+  user create -username NAME -role admin|viewer [-password PW]
+  user reset-password -username NAME [-password PW]
+  user disable -username NAME
+  user list [-search TEXT] [-limit N]
+  pengguna activate ID_PENGGUNA...
+  pengguna deactivate ID_PENGGUNA...
+  pengguna list [-search TEXT] [-jenis JENIS] [-limit N]
+  tokens revoke -username NAME
+  payment send -id-pengguna ID [-amount N] [-order-id ID] [-url URL]
+  dashboard
+
+passwords are read from stdin when -password is omitted.`
+
+type app struct {
+	cfg      config.Config
+	users    *service.UserService
+	pengguna *service.MasterPenggunaService
+	tokens   *service.TokenService
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	database, err := db.Open(cfg.DBDSN, 2, 2, 5*time.Minute)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	userRepo := repository.NewUserRepository(database)
+	a := &app{
+		cfg:      cfg,
+		users:    service.NewUserService(userRepo),
+		pengguna: service.NewMasterPenggunaService(repository.NewMasterPenggunaRepository(database)),
+		tokens:   service.NewTokenService(cfg.JWTSecret, repository.NewTokenRepository(database)),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := a.run(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "user":
+		if len(args) < 2 {
+			return flag.ErrHelp
+		}
+		switch args[1] {
+		case "create":
+			return a.userCreate(ctx, args[2:])
+		case "reset-password":
+			return a.userResetPassword(ctx, args[2:])
+		case "disable":
+			return a.userDisable(ctx, args[2:])
+		case "list":
+			return a.userList(ctx, args[2:])
+		}
+	case "pengguna":
+		if len(args) < 2 {
+			return flag.ErrHelp
+		}
+		switch args[1] {
+		case "activate":
+			return a.penggunaSetActive(ctx, args[2:], true)
+		case "deactivate":
+			return a.penggunaSetActive(ctx, args[2:], false)
+		case "list":
+			return a.penggunaList(ctx, args[2:])
+		}
+	case "tokens":
+		if len(args) >= 2 && args[1] == "revoke" {
+			return a.tokensRevoke(ctx, args[2:])
+		}
+	case "payment":
+		if len(args) >= 2 && args[1] == "send" {
+			return a.paymentSend(ctx, args[2:])
+		}
+	case "dashboard":
+		return a.dashboard(ctx)
+	}
+	return flag.ErrHelp
+}
+
+func (a *app) userCreate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+	username := fs.String("username", "", "username")
+	role := fs.String("role", "admin", "admin or viewer")
+	password := fs.String("password", "", "password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	pw, err := passwordOrStdin(*password)
+	if err != nil {
+		return err
+	}
+
+	uid, storedRole, err := a.users.Register(ctx, *username, pw, *role, actor)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s (%s) uid=%s\n", *username, storedRole, uid)
+	return nil
+}
+
+func (a *app) userResetPassword(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	username := fs.String("username", "", "username")
+	password := fs.String("password", "", "new password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := a.users.GetByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+	pw, err := passwordOrStdin(*password)
+	if err != nil {
+		return err
+	}
+
+	by := actor
+	if err := a.users.Update(ctx, user.UID, repository.UpdateUserRequest{Password: &pw, UpdatedBy: &by}); err != nil {
+		return err
+	}
+	fmt.Printf("password reset for %s\n", user.Username)
+	return nil
+}
+
+func (a *app) userDisable(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user disable", flag.ContinueOnError)
+	username := fs.String("username", "", "username")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := a.users.GetByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	active := false
+	by := actor
+	if err := a.users.Update(ctx, user.UID, repository.UpdateUserRequest{Active: &active, UpdatedBy: &by}); err != nil {
+		return err
+	}
+	revoked, err := a.tokens.RevokeUser(ctx, user.UID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("disabled %s, revoked %d refresh token(s)\n", user.Username, revoked)
+	return nil
+}
+
+func (a *app) userList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user list", flag.ContinueOnError)
+	search := fs.String("search", "", "filter by username")
+	limit := fs.Int("limit", 100, "max rows")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	q := repository.UserQuery.New(pagination.Params{Limit: *limit, Page: 1, Search: *search})
+	users, page, err := a.users.List(ctx, q, false)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "UID\tUSERNAME\tROLE\tACTIVE\tLAST SEEN")
+	for _, user := range users {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", user.UID, user.Username, user.Role, user.Active, formatTime(user.LastSeen))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d of %d user(s)\n", len(users), page.Total)
+	return nil
+}
+
+func (a *app) penggunaSetActive(ctx context.Context, args []string, active bool) error {
+	if len(args) == 0 {
+		return errors.New("at least one id_pengguna is required")
+	}
+
+	for _, raw := range args {
+		idPengguna, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid id_pengguna %q", raw)
+		}
+
+		item, err := a.pengguna.GetByIDPengguna(ctx, idPengguna)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("id_pengguna %d not found", idPengguna)
+			}
+			return err
+		}
+		if err := a.pengguna.Update(ctx, item.ID, repository.UpdateMasterPenggunaRequest{Active: &active}); err != nil {
+			return err
+		}
+		fmt.Printf("id_pengguna %d active=%t\n", idPengguna, active)
+	}
+	return nil
+}
+
+func (a *app) penggunaList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("pengguna list", flag.ContinueOnError)
+	search := fs.String("search", "", "filter by telegram or id_pengguna")
+	jenis := fs.String("jenis", "", "stockity, binomo or olymptrade")
+	limit := fs.Int("limit", 100, "max rows")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	q := repository.MasterPenggunaQuery.New(pagination.Params{Limit: *limit, Page: 1, Search: *search})
+	items, page, err := a.pengguna.List(ctx, q, *jenis, false)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tID_PENGGUNA\tTELEGRAM\tJENIS\tACTIVE\tCREATED")
+	for _, item := range items {
+		telegram := ""
+		if item.Telegram != nil {
+			telegram = *item.Telegram
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%t\t%s\n", item.ID, item.IDPengguna, telegram, item.Jenis, item.Active, formatTime(&item.CreatedAt))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d of %d pengguna\n", len(items), page.Total)
+	return nil
+}
+
+func (a *app) tokensRevoke(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tokens revoke", flag.ContinueOnError)
+	username := fs.String("username", "", "username")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := a.users.GetByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+	revoked, err := a.tokens.RevokeUser(ctx, user.UID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("revoked %d refresh token(s) for %s\n", revoked, user.Username)
+	return nil
+}
+
+func (a *app) paymentSend(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("payment send", flag.ContinueOnError)
+	idPengguna := fs.Int64("id-pengguna", 0, "id_pengguna the payment is for")
+	amount := fs.Float64("amount", 100000, "gross amount")
+	orderID := fs.String("order-id", "", "merchant order id suffix; payments are deduplicated on the order id, so resending one must be rejected")
+	target := fs.String("url", "http://localhost:"+a.cfg.Port+"/payments/webhook/midtrans", "webhook endpoint")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *idPengguna <= 0 {
+		return errors.New("-id-pengguna is required")
+	}
+	if a.cfg.MidtransServerKey == "" {
+		return errors.New("MIDTRANS_SERVER_KEY is not set")
+	}
+	transactionID := fmt.Sprintf("local-%d", time.Now().UnixNano())
+	if *orderID == "" {
+		*orderID = transactionID
+	}
+
+	order := payment.MidtransOrderID(*idPengguna, *orderID)
+	body, err := payment.NewMidtrans(a.cfg.MidtransServerKey).Sample(transactionID, order, *amount, time.Now())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	reply, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("order %s -> %s\n%s\n", order, resp.Status, strings.TrimSpace(string(reply)))
+	return nil
+}
+
+func (a *app) dashboard(ctx context.Context) error {
+	activeCount, inactiveCount, err := a.pengguna.Summary(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("total:    %d\nactive:   %d\ninactive: %d\n", activeCount+inactiveCount, activeCount, inactiveCount)
+	return nil
+}
+
+func passwordOrStdin(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("password is required")
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("password is required")
+	}
+	return line, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -1,4 +1,5 @@
 CREATE EXTENSION IF NOT EXISTS pgcrypto;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
 CREATE TABLE IF NOT EXISTS m_user (
     id BIGSERIAL PRIMARY KEY,
@@ -18,6 +19,7 @@ CREATE TABLE IF NOT EXISTS m_user (
 
 CREATE UNIQUE INDEX IF NOT EXISTS idx_m_user_uid ON m_user (uid);
 CREATE INDEX IF NOT EXISTS idx_m_user_username ON m_user (username);
+CREATE INDEX IF NOT EXISTS idx_m_user_created_at ON m_user (created_at, id);
 
 CREATE TABLE IF NOT EXISTS t_user_token (
     id BIGSERIAL PRIMARY KEY,
@@ -52,8 +54,19 @@ CREATE TABLE IF NOT EXISTS m_pengguna (
 ALTER TABLE m_pengguna
     ADD COLUMN IF NOT EXISTS jenis e_jenis_pengguna NOT NULL DEFAULT 'stockity';
 
+ALTER TABLE m_pengguna
+    ADD COLUMN IF NOT EXISTS activated_at TIMESTAMPTZ;
+
+UPDATE m_pengguna
+SET activated_at = COALESCE(updated_at, created_at)
+WHERE active AND activated_at IS NULL;
+
 CREATE UNIQUE INDEX IF NOT EXISTS idx_m_pengguna_uuid ON m_pengguna (uuid);
-CREATE UNIQUE INDEX IF NOT EXISTS idx_m_pengguna_id_pengguna ON m_pengguna (id_pengguna);
+DROP INDEX IF EXISTS idx_m_pengguna_id_pengguna;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_m_pengguna_id_pengguna_live ON m_pengguna (id_pengguna) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_m_pengguna_created_at ON m_pengguna (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_m_pengguna_activated_at ON m_pengguna (activated_at) WHERE activated_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_m_pengguna_id_pengguna_deleted ON m_pengguna (id_pengguna, deleted_at) WHERE deleted_at IS NOT NULL;
 
 CREATE TABLE IF NOT EXISTS t_pengguna_detail (
     id BIGINT PRIMARY KEY,
@@ -98,3 +111,394 @@ CREATE TABLE IF NOT EXISTS t_pengguna_detail (
 );
 
 CREATE INDEX IF NOT EXISTS idx_t_pengguna_detail_pengguna_id ON t_pengguna_detail (pengguna_id);
+
+ALTER TABLE m_pengguna
+    ADD COLUMN IF NOT EXISTS search_text TEXT,
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE OR REPLACE FUNCTION f_pengguna_search_text(p_id BIGINT) RETURNS TEXT AS $$
+    SELECT lower(concat_ws(' ',
+        m.telegram,
+        m.id_pengguna::text,
+        d.first_name,
+        d.last_name,
+        d.nickname,
+        d.email,
+        d.phone
+    ))
+    FROM m_pengguna m
+    LEFT JOIN LATERAL (
+        SELECT first_name, last_name, nickname, email, phone
+        FROM t_pengguna_detail
+        WHERE pengguna_id = m.id
+        ORDER BY id DESC
+        LIMIT 1
+    ) d ON TRUE
+    WHERE m.id = p_id
+$$ LANGUAGE sql STABLE;
+
+UPDATE m_pengguna
+SET search_text = f_pengguna_search_text(id),
+    search_vector = to_tsvector('simple', f_pengguna_search_text(id))
+WHERE search_vector IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_m_pengguna_search_vector ON m_pengguna USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_m_pengguna_search_text ON m_pengguna USING GIN (search_text gin_trgm_ops);
+
+ALTER TABLE m_pengguna
+    ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS t_pengguna_login (
+    id BIGSERIAL PRIMARY KEY,
+    pengguna_id BIGINT REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    id_pengguna BIGINT,
+    email TEXT,
+    outcome VARCHAR(32) NOT NULL,
+    device_id TEXT NOT NULL,
+    device_type TEXT NOT NULL,
+    ip TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_login_pengguna_id ON t_pengguna_login (pengguna_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_login_created_at ON t_pengguna_login (created_at);
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_detail_email ON t_pengguna_detail (lower(email));
+
+UPDATE t_pengguna_login l
+SET pengguna_id = d.pengguna_id,
+    id_pengguna = d.id_pengguna
+FROM (
+    SELECT DISTINCT ON (lower(d.email)) lower(d.email) AS email, m.id AS pengguna_id, m.id_pengguna
+    FROM t_pengguna_detail d
+    JOIN m_pengguna m ON m.id = d.pengguna_id
+    WHERE d.email IS NOT NULL AND m.deleted_at IS NULL
+    ORDER BY lower(d.email), d.id DESC
+) d
+WHERE l.pengguna_id IS NULL AND l.email = d.email;
+
+CREATE TABLE IF NOT EXISTS t_pengguna_alert (
+    id BIGSERIAL PRIMARY KEY,
+    pengguna_id BIGINT NOT NULL REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    detail JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_alert_pengguna_id ON t_pengguna_alert (pengguna_id, kind, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_alert_created_at ON t_pengguna_alert (created_at);
+
+CREATE TABLE IF NOT EXISTS t_pengguna_device (
+    id BIGSERIAL PRIMARY KEY,
+    pengguna_id BIGINT NOT NULL REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    device_id TEXT NOT NULL,
+    device_type TEXT NOT NULL,
+    last_ip TEXT,
+    bound_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_t_pengguna_device_pengguna_device ON t_pengguna_device (pengguna_id, device_id);
+
+CREATE TABLE IF NOT EXISTS t_pengguna_presence (
+    pengguna_id BIGINT PRIMARY KEY REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    device_id TEXT NOT NULL,
+    device_type TEXT,
+    client_version TEXT,
+    running BOOLEAN NOT NULL DEFAULT FALSE,
+    state TEXT,
+    ip TEXT,
+    last_seen_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_presence_last_seen_at ON t_pengguna_presence (last_seen_at);
+
+CREATE TABLE IF NOT EXISTS t_pengguna_command (
+    id BIGSERIAL PRIMARY KEY,
+    pengguna_id BIGINT NOT NULL REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    command VARCHAR(32) NOT NULL CHECK (command IN ('stop', 'pause', 'resume', 'reload_config')),
+    payload JSONB,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'acked', 'failed', 'expired', 'cancelled')),
+    device_id TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    result TEXT,
+    created_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    delivered_at TIMESTAMPTZ,
+    acked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_command_pengguna_id ON t_pengguna_command (pengguna_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_command_open ON t_pengguna_command (pengguna_id, id) WHERE status IN ('pending', 'delivered');
+
+CREATE TABLE IF NOT EXISTS m_bot_config_template (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    config JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(100),
+    updated_at TIMESTAMPTZ,
+    updated_by VARCHAR(100)
+);
+
+CREATE TABLE IF NOT EXISTS t_pengguna_config (
+    id BIGSERIAL PRIMARY KEY,
+    pengguna_id BIGINT NOT NULL REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    config JSONB NOT NULL,
+    source VARCHAR(16) NOT NULL CHECK (source IN ('manual', 'template', 'rollback')),
+    template_id BIGINT REFERENCES m_bot_config_template(id) ON DELETE SET NULL,
+    rollback_of INTEGER,
+    note TEXT,
+    created_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (pengguna_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS m_client_version (
+    device_type VARCHAR(50) PRIMARY KEY,
+    min_version VARCHAR(32),
+    recommended_version VARCHAR(32),
+    download_url TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by VARCHAR(100)
+);
+
+UPDATE m_client_version v
+SET device_type = lower(v.device_type)
+WHERE v.device_type <> lower(v.device_type)
+  AND NOT EXISTS (SELECT 1 FROM m_client_version o WHERE o.device_type = lower(v.device_type));
+
+CREATE TABLE IF NOT EXISTS t_pengguna_trade (
+    id BIGSERIAL,
+    pengguna_id BIGINT NOT NULL REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    client_trade_id VARCHAR(64) NOT NULL,
+    device_id TEXT,
+    asset VARCHAR(50) NOT NULL,
+    direction VARCHAR(8) NOT NULL CHECK (direction IN ('call', 'put')),
+    amount NUMERIC NOT NULL,
+    account_type VARCHAR(8) NOT NULL DEFAULT 'real' CHECK (account_type IN ('demo', 'real')),
+    opened_at TIMESTAMPTZ NOT NULL,
+    closed_at TIMESTAMPTZ,
+    result VARCHAR(8) CHECK (result IN ('win', 'loss', 'draw')),
+    payout NUMERIC,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id, opened_at)
+) PARTITION BY RANGE (opened_at);
+
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_trade_pengguna_id ON t_pengguna_trade (pengguna_id, opened_at);
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_trade_opened_at ON t_pengguna_trade (opened_at);
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_trade_asset ON t_pengguna_trade (asset, opened_at);
+
+CREATE TABLE IF NOT EXISTS t_pengguna_trade_key (
+    pengguna_id BIGINT NOT NULL REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    client_trade_id VARCHAR(64) NOT NULL,
+    opened_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pengguna_id, client_trade_id)
+);
+
+CREATE TABLE IF NOT EXISTS t_pengguna_balance_history (
+    id BIGSERIAL PRIMARY KEY,
+    pengguna_id BIGINT NOT NULL REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    balance NUMERIC NOT NULL,
+    balance_version BIGINT NOT NULL,
+    currency TEXT,
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (pengguna_id, balance_version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_balance_history_captured_at ON t_pengguna_balance_history (pengguna_id, captured_at);
+
+INSERT INTO t_pengguna_balance_history (pengguna_id, balance, balance_version, currency, captured_at)
+SELECT d.pengguna_id, d.balance, d.balance_version, d.currency, COALESCE(m.last_login_at, m.updated_at, m.created_at)
+FROM t_pengguna_detail d
+JOIN m_pengguna m ON m.id = d.pengguna_id
+ON CONFLICT (pengguna_id, balance_version) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS m_risk_plan (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    max_daily_loss NUMERIC,
+    max_trades_per_hour INTEGER,
+    max_stake_pct NUMERIC,
+    trading_start VARCHAR(5),
+    trading_end VARCHAR(5),
+    timezone VARCHAR(64),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(100),
+    updated_at TIMESTAMPTZ,
+    updated_by VARCHAR(100)
+);
+
+CREATE TABLE IF NOT EXISTS t_pengguna_risk (
+    pengguna_id BIGINT PRIMARY KEY REFERENCES m_pengguna(id) ON DELETE CASCADE,
+    plan_id BIGINT REFERENCES m_risk_plan(id) ON DELETE SET NULL,
+    max_daily_loss NUMERIC,
+    max_trades_per_hour INTEGER,
+    max_stake_pct NUMERIC,
+    trading_start VARCHAR(5),
+    trading_end VARCHAR(5),
+    timezone VARCHAR(64),
+    halted BOOLEAN NOT NULL DEFAULT FALSE,
+    halted_at TIMESTAMPTZ,
+    halt_rule VARCHAR(50),
+    halt_reason TEXT,
+    updated_at TIMESTAMPTZ,
+    updated_by VARCHAR(100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_risk_plan_id ON t_pengguna_risk (plan_id);
+CREATE INDEX IF NOT EXISTS idx_t_pengguna_risk_halted ON t_pengguna_risk (halted) WHERE halted;
+
+ALTER TABLE m_pengguna
+    ADD COLUMN IF NOT EXISTS active_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_m_pengguna_active_until ON m_pengguna (active_until)
+WHERE active AND active_until IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS t_webhook_event (
+    id BIGSERIAL PRIMARY KEY,
+    event VARCHAR(50) NOT NULL,
+    pengguna_id BIGINT,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_webhook_event_pending ON t_webhook_event (id) WHERE dispatched_at IS NULL;
+
+CREATE OR REPLACE FUNCTION f_pengguna_webhook_data(p m_pengguna) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'id', p.id,
+        'uuid', p.uuid,
+        'id_pengguna', p.id_pengguna,
+        'jenis', p.jenis,
+        'active', p.active,
+        'activated_at', p.activated_at,
+        'active_until', p.active_until
+    );
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION f_pengguna_webhook_event() RETURNS TRIGGER AS $$
+DECLARE
+    v_event VARCHAR(50);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO t_webhook_event (event, pengguna_id, data)
+        VALUES ('pengguna.registered', NEW.id, f_pengguna_webhook_data(NEW));
+        IF NEW.active THEN
+            v_event := 'pengguna.activated';
+        END IF;
+    ELSIF NEW.active AND NOT OLD.active THEN
+        v_event := 'pengguna.activated';
+    ELSIF OLD.active AND NOT NEW.active THEN
+        IF NEW.active_until IS NOT NULL AND NEW.active_until <= NOW() THEN
+            v_event := 'pengguna.expired';
+        ELSE
+            v_event := 'pengguna.deactivated';
+        END IF;
+    END IF;
+
+    IF v_event IS NOT NULL THEN
+        INSERT INTO t_webhook_event (event, pengguna_id, data)
+        VALUES (v_event, NEW.id, f_pengguna_webhook_data(NEW));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_m_pengguna_webhook_event ON m_pengguna;
+CREATE TRIGGER trg_m_pengguna_webhook_event
+    AFTER INSERT OR UPDATE OF active ON m_pengguna
+    FOR EACH ROW EXECUTE FUNCTION f_pengguna_webhook_event();
+
+CREATE OR REPLACE FUNCTION f_pengguna_risk_webhook_event() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.halted AND (TG_OP = 'INSERT' OR NOT OLD.halted) THEN
+        INSERT INTO t_webhook_event (event, pengguna_id, data)
+        SELECT 'pengguna.halted', p.id, f_pengguna_webhook_data(p) || jsonb_build_object(
+            'halt_rule', NEW.halt_rule,
+            'halt_reason', NEW.halt_reason,
+            'halted_at', NEW.halted_at
+        )
+        FROM m_pengguna p
+        WHERE p.id = NEW.pengguna_id;
+    ELSIF TG_OP = 'UPDATE' AND OLD.halted AND NOT NEW.halted THEN
+        INSERT INTO t_webhook_event (event, pengguna_id, data)
+        SELECT 'pengguna.resumed', p.id, f_pengguna_webhook_data(p)
+        FROM m_pengguna p
+        WHERE p.id = NEW.pengguna_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_t_pengguna_risk_webhook_event ON t_pengguna_risk;
+CREATE TRIGGER trg_t_pengguna_risk_webhook_event
+    AFTER INSERT OR UPDATE OF halted ON t_pengguna_risk
+    FOR EACH ROW EXECUTE FUNCTION f_pengguna_risk_webhook_event();
+
+CREATE TABLE IF NOT EXISTS m_webhook (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(100),
+    updated_at TIMESTAMPTZ,
+    updated_by VARCHAR(100)
+);
+
+CREATE TABLE IF NOT EXISTS t_webhook_delivery (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES m_webhook(id) ON DELETE CASCADE,
+    event_id BIGINT,
+    event VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivering', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER,
+    last_error TEXT,
+    last_response TEXT,
+    last_duration_ms INTEGER,
+    redelivery_of BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_webhook_delivery_due ON t_webhook_delivery (next_attempt_at)
+WHERE status IN ('pending', 'delivering');
+CREATE INDEX IF NOT EXISTS idx_t_webhook_delivery_webhook_id ON t_webhook_delivery (webhook_id, id DESC);
+
+CREATE TABLE IF NOT EXISTS t_payment (
+    id BIGSERIAL PRIMARY KEY,
+    provider VARCHAR(30) NOT NULL,
+    provider_payment_id VARCHAR(100) NOT NULL,
+    reference VARCHAR(100),
+    pengguna_id BIGINT REFERENCES m_pengguna(id) ON DELETE SET NULL,
+    id_pengguna BIGINT NOT NULL,
+    amount NUMERIC(18, 2) NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    period_days INTEGER NOT NULL,
+    active_until TIMESTAMPTZ,
+    paid_at TIMESTAMPTZ NOT NULL,
+    payload JSONB NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, provider_payment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_t_payment_id_pengguna ON t_payment (id_pengguna, received_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_t_payment_provider_reference ON t_payment (provider, reference);
+
+ALTER TABLE m_user
+    ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE m_user
+    ADD COLUMN IF NOT EXISTS active_before_delete BOOLEAN;
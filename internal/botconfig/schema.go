@@ -0,0 +1,171 @@
+package botconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidConfig = errors.New("invalid_config")
+
+type FieldType string
+
+const (
+	TypeString  FieldType = "string"
+	TypeNumber  FieldType = "number"
+	TypeInteger FieldType = "integer"
+	TypeBoolean FieldType = "boolean"
+	TypeStrings FieldType = "string_array"
+)
+
+type Field struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required"`
+	Min      *float64  `json:"min,omitempty"`
+	Max      *float64  `json:"max,omitempty"`
+	Enum     []string  `json:"enum,omitempty"`
+}
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+var Schema = []Field{
+	{Name: "account_type", Type: TypeString, Required: true, Enum: []string{"demo", "real"}},
+	{Name: "stake_mode", Type: TypeString, Required: true, Enum: []string{"fixed", "percent"}},
+	{Name: "stake_amount", Type: TypeNumber, Required: true, Min: bound(0)},
+	{Name: "assets", Type: TypeStrings},
+	{Name: "timeframe_sec", Type: TypeInteger, Min: bound(5), Max: bound(3600)},
+	{Name: "martingale_enabled", Type: TypeBoolean},
+	{Name: "martingale_multiplier", Type: TypeNumber, Min: bound(1), Max: bound(10)},
+	{Name: "martingale_max_steps", Type: TypeInteger, Min: bound(0), Max: bound(20)},
+	{Name: "max_daily_trades", Type: TypeInteger, Min: bound(0), Max: bound(10000)},
+	{Name: "take_profit", Type: TypeNumber, Min: bound(0)},
+	{Name: "stop_loss", Type: TypeNumber, Min: bound(0)},
+}
+
+func Validate(raw json.RawMessage) (json.RawMessage, error) {
+	var doc map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil || doc == nil {
+		return nil, &ValidationError{Problems: []string{"config must be a JSON object"}}
+	}
+
+	known := make(map[string]Field, len(Schema))
+	problems := make([]string, 0)
+	for _, field := range Schema {
+		known[field.Name] = field
+		val, ok := doc[field.Name]
+		if !ok || val == nil {
+			if field.Required {
+				problems = append(problems, field.Name+": required")
+			}
+			continue
+		}
+		if problem := checkField(field, val); problem != "" {
+			problems = append(problems, field.Name+": "+problem)
+		}
+	}
+
+	if doc["stake_mode"] == "percent" {
+		if num, ok := doc["stake_amount"].(json.Number); ok {
+			if f, err := num.Float64(); err == nil && f > 100 {
+				problems = append(problems, "stake_amount: must be at most 100 when stake_mode is percent")
+			}
+		}
+	}
+
+	unknown := make([]string, 0)
+	for name := range doc {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, name+": unknown field")
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func checkField(field Field, val any) string {
+	switch field.Type {
+	case TypeString:
+		s, ok := val.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if len(field.Enum) > 0 && !contains(field.Enum, s) {
+			return "must be one of " + strings.Join(field.Enum, ", ")
+		}
+	case TypeBoolean:
+		if _, ok := val.(bool); !ok {
+			return "must be a boolean"
+		}
+	case TypeNumber, TypeInteger:
+		num, ok := val.(json.Number)
+		if !ok {
+			return "must be a number"
+		}
+		f, err := num.Float64()
+		if err != nil {
+			return "must be a number"
+		}
+		if field.Type == TypeInteger && f != math.Trunc(f) {
+			return "must be an integer"
+		}
+		if field.Min != nil && f < *field.Min {
+			return fmt.Sprintf("must be at least %v", *field.Min)
+		}
+		if field.Max != nil && f > *field.Max {
+			return fmt.Sprintf("must be at most %v", *field.Max)
+		}
+	case TypeStrings:
+		items, ok := val.([]any)
+		if !ok {
+			return "must be an array of strings"
+		}
+		for _, item := range items {
+			if s, ok := item.(string); !ok || strings.TrimSpace(s) == "" {
+				return "must be an array of non-empty strings"
+			}
+		}
+	}
+	return ""
+}
+
+func contains(values []string, val string) bool {
+	for _, v := range values {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package botconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		problems []string
+	}{
+		{
+			name:   "minimal",
+			config: `{"account_type":"demo","stake_mode":"fixed","stake_amount":10}`,
+		},
+		{
+			name: "all fields",
+			config: `{"account_type":"real","stake_mode":"percent","stake_amount":2.5,"assets":["EURUSD"],
+				"timeframe_sec":60,"martingale_enabled":true,"martingale_multiplier":2,"martingale_max_steps":3,
+				"max_daily_trades":100,"take_profit":50,"stop_loss":25}`,
+		},
+		{
+			name:     "not an object",
+			config:   `[1,2]`,
+			problems: []string{"config must be a JSON object"},
+		},
+		{
+			name:     "null",
+			config:   `null`,
+			problems: []string{"config must be a JSON object"},
+		},
+		{
+			name:     "missing required",
+			config:   `{}`,
+			problems: []string{"account_type: required", "stake_mode: required", "stake_amount: required"},
+		},
+		{
+			name:     "enum",
+			config:   `{"account_type":"live","stake_mode":"fixed","stake_amount":10}`,
+			problems: []string{"account_type: must be one of demo, real"},
+		},
+		{
+			name:     "wrong types",
+			config:   `{"account_type":1,"stake_mode":"fixed","stake_amount":"10","martingale_enabled":"yes"}`,
+			problems: []string{"account_type: must be a string", "stake_amount: must be a number", "martingale_enabled: must be a boolean"},
+		},
+		{
+			name:     "integer and bounds",
+			config:   `{"account_type":"demo","stake_mode":"fixed","stake_amount":-1,"timeframe_sec":1.5,"martingale_max_steps":21}`,
+			problems: []string{"stake_amount: must be at least 0", "timeframe_sec: must be an integer", "martingale_max_steps: must be at most 20"},
+		},
+		{
+			name:     "percent stake",
+			config:   `{"account_type":"demo","stake_mode":"percent","stake_amount":150}`,
+			problems: []string{"stake_amount: must be at most 100 when stake_mode is percent"},
+		},
+		{
+			name:     "assets",
+			config:   `{"account_type":"demo","stake_mode":"fixed","stake_amount":1,"assets":["EURUSD"," "]}`,
+			problems: []string{"assets: must be an array of non-empty strings"},
+		},
+		{
+			name:     "unknown fields sorted",
+			config:   `{"account_type":"demo","stake_mode":"fixed","stake_amount":1,"zeta":1,"alpha":2}`,
+			problems: []string{"alpha: unknown field", "zeta: unknown field"},
+		},
+		{
+			name:   "null optional field",
+			config: `{"account_type":"demo","stake_mode":"fixed","stake_amount":1,"stop_loss":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := Validate(json.RawMessage(tt.config))
+			if tt.problems == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				if !json.Valid(normalized) {
+					t.Fatalf("normalized config is not JSON: %s", normalized)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want ValidationError", err)
+			}
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("error does not wrap ErrInvalidConfig")
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.problems) {
+				t.Errorf("Problems = %q, want %q", validationErr.Problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestValidateKeepsNumberPrecision(t *testing.T) {
+	normalized, err := Validate(json.RawMessage(`{"account_type":"demo","stake_mode":"fixed","stake_amount":12345678901234567890}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"account_type":"demo","stake_amount":12345678901234567890,"stake_mode":"fixed"}`
+	if string(normalized) != want {
+		t.Errorf("normalized = %s, want %s", normalized, want)
+	}
+}
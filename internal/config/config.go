@@ -1,13 +1,19 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"koalbot_api/internal/logging"
 )
 
+const minJWTSecretLength = 32
+
 type Config struct {
 	DBDSN                string
 	Port                 string
@@ -28,123 +34,302 @@ type Config struct {
 	StockityBaseURL      string
 	CORSAllowedOrigins   []string
 	CORSAllowCredentials bool
+	TracingExporter      string
+	TracingOTLPEndpoint  string
+	TracingOTLPInsecure  bool
+	TracingServiceName   string
+	TracingSampleRatio   float64
+	ShutdownDrainDelay   time.Duration
+	StockityProbeEvery   time.Duration
+	StockityTimeout      time.Duration
+	LogLevel             string
+	PurgeAfter           time.Duration
+	PurgeInterval        time.Duration
+	LoginAlertDevices    int
+	LoginAlertWindow     time.Duration
+	MaxDevices           int
+	DeviceLimitPolicy    string
+	DeviceIdle           time.Duration
+	PresenceTTL          time.Duration
+	PresencePersistEvery time.Duration
+	WebhookTimeout       time.Duration
+	WebhookMaxAttempts   int
+	WebhookRetryBase     time.Duration
+	MidtransServerKey    string
+	PaymentPeriodDays    int
 }
 
-func Load() Config {
-	dsn := os.Getenv("DB_DSN")
-	if dsn == "" {
-		log.Fatal("DB_DSN is required")
-	}
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET is required")
-	}
-
-	migrationPath := os.Getenv("MIGRATION_PATH")
-	if migrationPath == "" {
-		migrationPath = "db/schema.sql"
-	}
-
-	stockityBaseURL := os.Getenv("STOCKITY_BASE_URL")
-	if stockityBaseURL == "" {
-		stockityBaseURL = "https://api.stockity.id"
-	}
-	corsAllowedOrigins := getSliceEnv("CORS_ALLOWED_ORIGINS")
-	corsAllowCredentials := getBoolEnv("CORS_ALLOW_CREDENTIALS", false)
-
-	maxBodyBytes := int64(getIntEnv("MAX_BODY_BYTES", 1<<20))
-	readTimeout := time.Duration(getIntEnv("READ_TIMEOUT_SEC", 10)) * time.Second
-	writeTimeout := time.Duration(getIntEnv("WRITE_TIMEOUT_SEC", 10)) * time.Second
-	idleTimeout := time.Duration(getIntEnv("IDLE_TIMEOUT_SEC", 60)) * time.Second
-	rateLimitRPS := getFloatEnv("RATE_LIMIT_RPS", 20)
-	rateLimitBurst := getIntEnv("RATE_LIMIT_BURST", 40)
-	loginRateLimitRPS := getFloatEnv("LOGIN_RATE_LIMIT_RPS", 5)
-	loginRateLimitBurst := getIntEnv("LOGIN_RATE_LIMIT_BURST", 10)
-	trustedProxies := getSliceEnv("TRUSTED_PROXIES")
-	dbMaxOpenConns := getIntEnv("DB_MAX_OPEN_CONNS", 25)
-	dbMaxIdleConns := getIntEnv("DB_MAX_IDLE_CONNS", 25)
-	dbConnMaxLifetime := time.Duration(getIntEnv("DB_CONN_MAX_LIFETIME_MIN", 15)) * time.Minute
-
-	return Config{
-		DBDSN:                dsn,
-		Port:                 port,
-		JWTSecret:            jwtSecret,
-		MigrationPath:        migrationPath,
-		MaxBodyBytes:         maxBodyBytes,
-		ReadTimeout:          readTimeout,
-		WriteTimeout:         writeTimeout,
-		IdleTimeout:          idleTimeout,
-		RateLimitRPS:         rateLimitRPS,
-		RateLimitBurst:       rateLimitBurst,
-		LoginRateLimitRPS:    loginRateLimitRPS,
-		LoginRateLimitBurst:  loginRateLimitBurst,
-		TrustedProxies:       trustedProxies,
-		DBMaxOpenConns:       dbMaxOpenConns,
-		DBMaxIdleConns:       dbMaxIdleConns,
-		DBConnMaxLifetime:    dbConnMaxLifetime,
-		StockityBaseURL:      stockityBaseURL,
-		CORSAllowedOrigins:   corsAllowedOrigins,
-		CORSAllowCredentials: corsAllowCredentials,
+func Load() (Config, error) {
+	src, err := newSource(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return Config{}, err
+	}
+
+	p := &parser{src: src}
+	cfg := Config{
+		DBDSN:                p.required("DB_DSN"),
+		Port:                 p.string("PORT", "8080"),
+		JWTSecret:            p.required("JWT_SECRET"),
+		MigrationPath:        p.string("MIGRATION_PATH", "db/schema.sql"),
+		MaxBodyBytes:         int64(p.int("MAX_BODY_BYTES", 1<<20)),
+		ReadTimeout:          time.Duration(p.int("READ_TIMEOUT_SEC", 10)) * time.Second,
+		WriteTimeout:         time.Duration(p.int("WRITE_TIMEOUT_SEC", 10)) * time.Second,
+		IdleTimeout:          time.Duration(p.int("IDLE_TIMEOUT_SEC", 60)) * time.Second,
+		RateLimitRPS:         p.float("RATE_LIMIT_RPS", 20),
+		RateLimitBurst:       p.int("RATE_LIMIT_BURST", 40),
+		LoginRateLimitRPS:    p.float("LOGIN_RATE_LIMIT_RPS", 5),
+		LoginRateLimitBurst:  p.int("LOGIN_RATE_LIMIT_BURST", 10),
+		TrustedProxies:       p.slice("TRUSTED_PROXIES"),
+		DBMaxOpenConns:       p.int("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:       p.int("DB_MAX_IDLE_CONNS", 25),
+		DBConnMaxLifetime:    time.Duration(p.int("DB_CONN_MAX_LIFETIME_MIN", 15)) * time.Minute,
+		StockityBaseURL:      p.string("STOCKITY_BASE_URL", "https://api.stockity.id"),
+		CORSAllowedOrigins:   p.slice("CORS_ALLOWED_ORIGINS"),
+		CORSAllowCredentials: p.bool("CORS_ALLOW_CREDENTIALS", false),
+		TracingExporter:      p.string("TRACING_EXPORTER", "none"),
+		TracingOTLPEndpoint:  p.string("TRACING_OTLP_ENDPOINT", ""),
+		TracingOTLPInsecure:  p.bool("TRACING_OTLP_INSECURE", false),
+		TracingServiceName:   p.string("TRACING_SERVICE_NAME", "koalbot_api"),
+		TracingSampleRatio:   p.float("TRACING_SAMPLE_RATIO", 1),
+		ShutdownDrainDelay:   time.Duration(p.int("SHUTDOWN_DRAIN_SEC", 5)) * time.Second,
+		StockityProbeEvery:   time.Duration(p.int("STOCKITY_PROBE_INTERVAL_SEC", 30)) * time.Second,
+		StockityTimeout:      time.Duration(p.int("STOCKITY_TIMEOUT_SEC", 15)) * time.Second,
+		LogLevel:             p.string("LOG_LEVEL", "info"),
+		PurgeAfter:           time.Duration(p.int("PURGE_AFTER_DAYS", 0)) * 24 * time.Hour,
+		PurgeInterval:        time.Duration(p.int("PURGE_INTERVAL_MIN", 60)) * time.Minute,
+		LoginAlertDevices:    p.int("LOGIN_ALERT_DEVICES", 3),
+		LoginAlertWindow:     time.Duration(p.int("LOGIN_ALERT_WINDOW_HOURS", 24)) * time.Hour,
+		MaxDevices:           p.int("MAX_DEVICES", 1),
+		DeviceLimitPolicy:    p.string("DEVICE_LIMIT_POLICY", "reject"),
+		DeviceIdle:           time.Duration(p.int("DEVICE_IDLE_DAYS", 30)) * 24 * time.Hour,
+		PresenceTTL:          time.Duration(p.int("PRESENCE_TTL_SEC", 90)) * time.Second,
+		PresencePersistEvery: time.Duration(p.int("PRESENCE_PERSIST_SEC", 60)) * time.Second,
+		WebhookTimeout:       time.Duration(p.int("WEBHOOK_TIMEOUT_SEC", 10)) * time.Second,
+		WebhookMaxAttempts:   p.int("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:     time.Duration(p.int("WEBHOOK_RETRY_BASE_SEC", 30)) * time.Second,
+		MidtransServerKey:    p.string("MIDTRANS_SERVER_KEY", ""),
+		PaymentPeriodDays:    p.int("PAYMENT_PERIOD_DAYS", 30),
 	}
+
+	for _, key := range src.unknownKeys() {
+		p.errs = append(p.errs, fmt.Errorf("%s: unknown key in config file", key))
+	}
+
+	errs := append(p.errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+	return cfg, nil
 }
 
-func getIntEnv(key string, def int) int {
-	val := os.Getenv(key)
-	if val == "" {
+func (c Config) validate() []error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.DBDSN != "" && !strings.Contains(c.DBDSN, "=") {
+		if err := checkURL(c.DBDSN, "postgres", "postgresql"); err != nil {
+			fail("DB_DSN: %v", err)
+		}
+	}
+	if c.JWTSecret != "" && len(c.JWTSecret) < minJWTSecretLength {
+		fail("JWT_SECRET: must be at least %d characters", minJWTSecretLength)
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("PORT: must be a number between 1 and 65535")
+	}
+	if c.MaxBodyBytes <= 0 {
+		fail("MAX_BODY_BYTES: must be positive")
+	}
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.IdleTimeout <= 0 {
+		fail("READ_TIMEOUT_SEC, WRITE_TIMEOUT_SEC and IDLE_TIMEOUT_SEC: must be positive")
+	}
+	if c.RateLimitRPS <= 0 || c.RateLimitBurst < 1 {
+		fail("RATE_LIMIT_RPS and RATE_LIMIT_BURST: must be positive")
+	}
+	if c.LoginRateLimitRPS <= 0 || c.LoginRateLimitBurst < 1 {
+		fail("LOGIN_RATE_LIMIT_RPS and LOGIN_RATE_LIMIT_BURST: must be positive")
+	}
+	if c.LoginRateLimitRPS > c.RateLimitRPS {
+		fail("LOGIN_RATE_LIMIT_RPS: must not exceed RATE_LIMIT_RPS")
+	}
+	if c.DBMaxOpenConns < 0 || c.DBMaxIdleConns < 0 {
+		fail("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS: must not be negative")
+	}
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		fail("DB_MAX_IDLE_CONNS: must not exceed DB_MAX_OPEN_CONNS")
+	}
+	if err := checkURL(c.StockityBaseURL, "http", "https"); err != nil {
+		fail("STOCKITY_BASE_URL: %v", err)
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if err := checkURL(origin, "http", "https"); err != nil {
+			fail("CORS_ALLOWED_ORIGINS: %q: %v", origin, err)
+		}
+	}
+	if c.CORSAllowCredentials && len(c.CORSAllowedOrigins) == 0 {
+		fail("CORS_ALLOW_CREDENTIALS: requires CORS_ALLOWED_ORIGINS")
+	}
+	switch c.TracingExporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.TracingOTLPEndpoint != "" {
+			if err := checkURL(c.TracingOTLPEndpoint, "http", "https"); err != nil {
+				fail("TRACING_OTLP_ENDPOINT: %v", err)
+			}
+		}
+	default:
+		fail("TRACING_EXPORTER: must be one of none, stdout, otlp")
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		fail("TRACING_SAMPLE_RATIO: must be between 0 and 1")
+	}
+	if c.ShutdownDrainDelay < 0 {
+		fail("SHUTDOWN_DRAIN_SEC: must not be negative")
+	}
+	if c.StockityProbeEvery <= 0 {
+		fail("STOCKITY_PROBE_INTERVAL_SEC: must be positive")
+	}
+	if c.StockityTimeout <= 0 {
+		fail("STOCKITY_TIMEOUT_SEC: must be positive")
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		fail("LOG_LEVEL: must be one of debug, info, warn, error")
+	}
+	if c.PurgeAfter < 0 {
+		fail("PURGE_AFTER_DAYS: must not be negative")
+	}
+	if c.PurgeInterval <= 0 {
+		fail("PURGE_INTERVAL_MIN: must be positive")
+	}
+	if c.LoginAlertDevices < 0 {
+		fail("LOGIN_ALERT_DEVICES: must not be negative")
+	}
+	if c.LoginAlertWindow <= 0 {
+		fail("LOGIN_ALERT_WINDOW_HOURS: must be positive")
+	}
+	if c.MaxDevices < 0 {
+		fail("MAX_DEVICES: must not be negative")
+	}
+	switch c.DeviceLimitPolicy {
+	case "reject", "evict":
+	default:
+		fail("DEVICE_LIMIT_POLICY: must be one of reject, evict")
+	}
+	if c.DeviceIdle < 0 {
+		fail("DEVICE_IDLE_DAYS: must not be negative")
+	}
+	if c.PresenceTTL < 3*time.Second {
+		fail("PRESENCE_TTL_SEC: must be at least 3")
+	}
+	if c.PresencePersistEvery <= 0 {
+		fail("PRESENCE_PERSIST_SEC: must be positive")
+	}
+	if c.WebhookTimeout <= 0 {
+		fail("WEBHOOK_TIMEOUT_SEC: must be positive")
+	}
+	if c.WebhookMaxAttempts < 1 {
+		fail("WEBHOOK_MAX_ATTEMPTS: must be at least 1")
+	}
+	if c.WebhookRetryBase <= 0 {
+		fail("WEBHOOK_RETRY_BASE_SEC: must be positive")
+	}
+	if c.PaymentPeriodDays < 1 {
+		fail("PAYMENT_PERIOD_DAYS: must be at least 1")
+	}
+
+	return errs
+}
+
+func checkURL(raw string, schemes ...string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if parsed.Host == "" {
+		return errors.New("missing host")
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("scheme must be one of %s", strings.Join(schemes, ", "))
+}
+
+type parser struct {
+	src  source
+	errs []error
+}
+
+func (p *parser) string(key, def string) string {
+	val, ok := p.src.lookup(key)
+	if !ok {
+		return def
+	}
+	return val
+}
+
+func (p *parser) required(key string) string {
+	val, ok := p.src.lookup(key)
+	if !ok {
+		p.errs = append(p.errs, fmt.Errorf("%s: is required", key))
+	}
+	return val
+}
+
+func (p *parser) int(key string, def int) int {
+	val, ok := p.src.lookup(key)
+	if !ok {
 		return def
 	}
 	parsed, err := strconv.Atoi(val)
 	if err != nil {
-		log.Printf("invalid %s, using default", key)
+		p.errs = append(p.errs, fmt.Errorf("%s: invalid integer %q", key, val))
 		return def
 	}
 	return parsed
 }
 
-func getFloatEnv(key string, def float64) float64 {
-	val := os.Getenv(key)
-	if val == "" {
+func (p *parser) float(key string, def float64) float64 {
+	val, ok := p.src.lookup(key)
+	if !ok {
 		return def
 	}
 	parsed, err := strconv.ParseFloat(val, 64)
 	if err != nil {
-		log.Printf("invalid %s, using default", key)
+		p.errs = append(p.errs, fmt.Errorf("%s: invalid number %q", key, val))
+		return def
+	}
+	return parsed
+}
+
+func (p *parser) bool(key string, def bool) bool {
+	val, ok := p.src.lookup(key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.ParseBool(val)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s: invalid boolean %q", key, val))
 		return def
 	}
 	return parsed
 }
 
-func getSliceEnv(key string) []string {
-	val := os.Getenv(key)
-	if val == "" {
+func (p *parser) slice(key string) []string {
+	val, ok := p.src.lookup(key)
+	if !ok {
 		return nil
 	}
 	parts := strings.Split(val, ",")
 	out := make([]string, 0, len(parts))
-	for _, p := range parts {
-		trimmed := strings.TrimSpace(p)
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
 		if trimmed != "" {
 			out = append(out, trimmed)
 		}
 	}
 	return out
 }
-
-func getBoolEnv(key string, def bool) bool {
-	val := os.Getenv(key)
-	if val == "" {
-		return def
-	}
-	parsed, err := strconv.ParseBool(val)
-	if err != nil {
-		log.Printf("invalid %s, using default", key)
-		return def
-	}
-	return parsed
-}
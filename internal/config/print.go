@@ -0,0 +1,112 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const masked = "****"
+
+type Setting struct {
+	Key   string
+	Value string
+}
+
+func (c Config) Settings() []Setting {
+	return []Setting{
+		{"DB_DSN", maskDSN(c.DBDSN)},
+		{"PORT", c.Port},
+		{"JWT_SECRET", masked},
+		{"MIGRATION_PATH", c.MigrationPath},
+		{"MAX_BODY_BYTES", strconv.FormatInt(c.MaxBodyBytes, 10)},
+		{"READ_TIMEOUT_SEC", formatSeconds(c.ReadTimeout)},
+		{"WRITE_TIMEOUT_SEC", formatSeconds(c.WriteTimeout)},
+		{"IDLE_TIMEOUT_SEC", formatSeconds(c.IdleTimeout)},
+		{"RATE_LIMIT_RPS", formatFloat(c.RateLimitRPS)},
+		{"RATE_LIMIT_BURST", strconv.Itoa(c.RateLimitBurst)},
+		{"LOGIN_RATE_LIMIT_RPS", formatFloat(c.LoginRateLimitRPS)},
+		{"LOGIN_RATE_LIMIT_BURST", strconv.Itoa(c.LoginRateLimitBurst)},
+		{"TRUSTED_PROXIES", strings.Join(c.TrustedProxies, ",")},
+		{"DB_MAX_OPEN_CONNS", strconv.Itoa(c.DBMaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", strconv.Itoa(c.DBMaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME_MIN", strconv.Itoa(int(c.DBConnMaxLifetime / time.Minute))},
+		{"STOCKITY_BASE_URL", c.StockityBaseURL},
+		{"CORS_ALLOWED_ORIGINS", strings.Join(c.CORSAllowedOrigins, ",")},
+		{"CORS_ALLOW_CREDENTIALS", strconv.FormatBool(c.CORSAllowCredentials)},
+		{"TRACING_EXPORTER", c.TracingExporter},
+		{"TRACING_OTLP_ENDPOINT", c.TracingOTLPEndpoint},
+		{"TRACING_OTLP_INSECURE", strconv.FormatBool(c.TracingOTLPInsecure)},
+		{"TRACING_SERVICE_NAME", c.TracingServiceName},
+		{"TRACING_SAMPLE_RATIO", formatFloat(c.TracingSampleRatio)},
+		{"SHUTDOWN_DRAIN_SEC", formatSeconds(c.ShutdownDrainDelay)},
+		{"STOCKITY_PROBE_INTERVAL_SEC", formatSeconds(c.StockityProbeEvery)},
+		{"STOCKITY_TIMEOUT_SEC", formatSeconds(c.StockityTimeout)},
+		{"LOG_LEVEL", c.LogLevel},
+		{"PURGE_AFTER_DAYS", strconv.Itoa(int(c.PurgeAfter / (24 * time.Hour)))},
+		{"PURGE_INTERVAL_MIN", strconv.Itoa(int(c.PurgeInterval / time.Minute))},
+		{"LOGIN_ALERT_DEVICES", strconv.Itoa(c.LoginAlertDevices)},
+		{"LOGIN_ALERT_WINDOW_HOURS", strconv.Itoa(int(c.LoginAlertWindow / time.Hour))},
+		{"MAX_DEVICES", strconv.Itoa(c.MaxDevices)},
+		{"DEVICE_LIMIT_POLICY", c.DeviceLimitPolicy},
+		{"DEVICE_IDLE_DAYS", strconv.Itoa(int(c.DeviceIdle / (24 * time.Hour)))},
+		{"PRESENCE_TTL_SEC", formatSeconds(c.PresenceTTL)},
+		{"PRESENCE_PERSIST_SEC", formatSeconds(c.PresencePersistEvery)},
+		{"WEBHOOK_TIMEOUT_SEC", formatSeconds(c.WebhookTimeout)},
+		{"WEBHOOK_MAX_ATTEMPTS", strconv.Itoa(c.WebhookMaxAttempts)},
+		{"WEBHOOK_RETRY_BASE_SEC", formatSeconds(c.WebhookRetryBase)},
+		{"MIDTRANS_SERVER_KEY", maskOptional(c.MidtransServerKey)},
+		{"PAYMENT_PERIOD_DAYS", strconv.Itoa(c.PaymentPeriodDays)},
+	}
+}
+
+func (c Config) Print(w io.Writer) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, setting := range c.Settings() {
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: strings.ToLower(setting.Key)},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: setting.Value},
+		)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func maskDSN(dsn string) string {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.User == nil {
+		if strings.Contains(dsn, "password=") {
+			return masked
+		}
+		return dsn
+	}
+	password, ok := parsed.User.Password()
+	if !ok || password == "" {
+		return dsn
+	}
+	return parsed.Redacted()
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.Itoa(int(d / time.Second))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func maskOptional(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return masked
+}
@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestMaskDSN(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{
+			dsn:  "postgres://koalbot:secret@db:5432/koalbot?sslmode=disable",
+			want: "postgres://koalbot:xxxxx@db:5432/koalbot?sslmode=disable",
+		},
+		{
+			dsn:  "postgres://koalbot:p%40ss%3Aword@db/koalbot",
+			want: "postgres://koalbot:xxxxx@db/koalbot",
+		},
+		{
+			dsn:  "postgres://koalbot@db/koalbot",
+			want: "postgres://koalbot@db/koalbot",
+		},
+		{
+			dsn:  "host=db user=koalbot password=secret dbname=koalbot",
+			want: masked,
+		},
+		{
+			dsn:  "host=db user=koalbot dbname=koalbot",
+			want: "host=db user=koalbot dbname=koalbot",
+		},
+	}
+	for _, tt := range tests {
+		if got := maskDSN(tt.dsn); got != tt.want {
+			t.Errorf("maskDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
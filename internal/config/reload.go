@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"sort"
+)
+
+var reloadableKeys = map[string]bool{
+	"CORS_ALLOWED_ORIGINS":   true,
+	"CORS_ALLOW_CREDENTIALS": true,
+	"RATE_LIMIT_RPS":         true,
+	"RATE_LIMIT_BURST":       true,
+	"LOGIN_RATE_LIMIT_RPS":   true,
+	"LOGIN_RATE_LIMIT_BURST": true,
+	"LOG_LEVEL":              true,
+	"STOCKITY_BASE_URL":      true,
+	"STOCKITY_TIMEOUT_SEC":   true,
+	"MAX_DEVICES":            true,
+	"DEVICE_LIMIT_POLICY":    true,
+	"DEVICE_IDLE_DAYS":       true,
+}
+
+func EnvOverrides() []string {
+	keys := make([]string, 0)
+	for key := range reloadableKeys {
+		if os.Getenv(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+func Diff(current, next Config) []Change {
+	nextSettings := next.Settings()
+	changes := make([]Change, 0)
+	for i, setting := range current.Settings() {
+		if setting.Value != nextSettings[i].Value {
+			changes = append(changes, Change{Key: setting.Key, Old: setting.Value, New: nextSettings[i].Value})
+		}
+	}
+	return changes
+}
+
+func Reload(current, next Config) (Config, []Change, []Change) {
+	var applied, skipped []Change
+	for _, change := range Diff(current, next) {
+		if reloadableKeys[change.Key] {
+			applied = append(applied, change)
+		} else {
+			skipped = append(skipped, change)
+		}
+	}
+
+	merged := current
+	merged.CORSAllowedOrigins = next.CORSAllowedOrigins
+	merged.CORSAllowCredentials = next.CORSAllowCredentials
+	merged.RateLimitRPS = next.RateLimitRPS
+	merged.RateLimitBurst = next.RateLimitBurst
+	merged.LoginRateLimitRPS = next.LoginRateLimitRPS
+	merged.LoginRateLimitBurst = next.LoginRateLimitBurst
+	merged.LogLevel = next.LogLevel
+	merged.StockityBaseURL = next.StockityBaseURL
+	merged.StockityTimeout = next.StockityTimeout
+	merged.MaxDevices = next.MaxDevices
+	merged.DeviceLimitPolicy = next.DeviceLimitPolicy
+	merged.DeviceIdle = next.DeviceIdle
+
+	return merged, applied, skipped
+}
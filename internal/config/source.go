@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type source struct {
+	file map[string]string
+	seen map[string]bool
+}
+
+func newSource(path string) (source, error) {
+	if path == "" {
+		return source{}, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return source{}, fmt.Errorf("read config file: %w", err)
+	}
+
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &values)
+	case ".toml":
+		err = toml.Unmarshal(raw, &values)
+	default:
+		return source{}, fmt.Errorf("unsupported config file extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return source{}, fmt.Errorf("parse config file: %w", err)
+	}
+
+	file := make(map[string]string, len(values))
+	for key, val := range values {
+		flat, err := flattenValue(val)
+		if err != nil {
+			return source{}, fmt.Errorf("config file key %s: %w", key, err)
+		}
+		file[strings.ToUpper(key)] = flat
+	}
+
+	return source{file: file, seen: make(map[string]bool, len(file))}, nil
+}
+
+func (s source) lookup(key string) (string, bool) {
+	if s.seen != nil {
+		s.seen[key] = true
+	}
+	if val := os.Getenv(key); val != "" {
+		return val, true
+	}
+	val, ok := s.file[key]
+	if !ok || val == "" {
+		return "", false
+	}
+	return val, true
+}
+
+func (s source) unknownKeys() []string {
+	var keys []string
+	for key := range s.file {
+		if !s.seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func flattenValue(val any) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			flat, err := flattenValue(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, flat)
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]any:
+		return "", fmt.Errorf("nested sections are not supported")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/service"
+)
+
+const defaultBalanceDays = 30
+
+type BalanceHistoryHandler struct {
+	service *service.BalanceHistoryService
+}
+
+func NewBalanceHistoryHandler(service *service.BalanceHistoryService) *BalanceHistoryHandler {
+	return &BalanceHistoryHandler{service: service}
+}
+
+type balancePoint struct {
+	Bucket  time.Time `json:"bucket"`
+	Open    float64   `json:"open"`
+	High    float64   `json:"high"`
+	Low     float64   `json:"low"`
+	Close   float64   `json:"close"`
+	Samples int       `json:"samples"`
+}
+
+func (h *BalanceHistoryHandler) Series(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	from, to, ok := parseDateRange(c, time.Now().UTC().AddDate(0, 0, -defaultBalanceDays))
+	if !ok {
+		return
+	}
+
+	points, resolution, err := h.service.Series(c.Request.Context(), id, from, to, c.DefaultQuery("resolution", service.ResolutionAuto))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidResolution):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_resolution"})
+		case errors.Is(err, service.ErrInvalidRange):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_range"})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	items := make([]balancePoint, 0, len(points))
+	for _, point := range points {
+		items = append(items, balancePoint{
+			Bucket:  point.Bucket,
+			Open:    point.Open,
+			High:    point.High,
+			Low:     point.Low,
+			Close:   point.Close,
+			Samples: point.Samples,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":       from,
+		"to":         to,
+		"resolution": resolution,
+		"data":       items,
+	})
+}
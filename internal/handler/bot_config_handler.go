@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/botconfig"
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+type BotConfigHandler struct {
+	service *service.BotConfigService
+}
+
+func NewBotConfigHandler(service *service.BotConfigService) *BotConfigHandler {
+	return &BotConfigHandler{service: service}
+}
+
+type createTemplateRequest struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Config      json.RawMessage `json:"config"`
+}
+
+type updateTemplateRequest struct {
+	Name        *string         `json:"name"`
+	Description *string         `json:"description"`
+	Config      json.RawMessage `json:"config"`
+}
+
+type assignTemplateRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+type setConfigRequest struct {
+	Config json.RawMessage `json:"config"`
+	Note   string          `json:"note"`
+}
+
+type rollbackConfigRequest struct {
+	Version int    `json:"version"`
+	Note    string `json:"note"`
+}
+
+type templateItem struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Config      json.RawMessage `json:"config"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CreatedBy   string          `json:"created_by"`
+	UpdatedAt   *time.Time      `json:"updated_at"`
+	UpdatedBy   string          `json:"updated_by"`
+}
+
+type penggunaConfigItem struct {
+	Version    int             `json:"version"`
+	Config     json.RawMessage `json:"config"`
+	Source     string          `json:"source"`
+	TemplateID *int64          `json:"template_id"`
+	RollbackOf *int            `json:"rollback_of"`
+	Note       string          `json:"note"`
+	CreatedBy  string          `json:"created_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ETag       string          `json:"etag"`
+}
+
+func (h *BotConfigHandler) Schema(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"fields": botconfig.Schema})
+}
+
+func (h *BotConfigHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]templateItem, 0, len(templates))
+	for _, template := range templates {
+		items = append(items, newTemplateItem(template))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+func (h *BotConfigHandler) GetTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.service.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTemplateItem(template))
+}
+
+func (h *BotConfigHandler) CreateTemplate(c *gin.Context) {
+	var req createTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	template, err := h.service.CreateTemplate(c.Request.Context(), req.Name, req.Description, req.Config, authCtx.UID)
+	if err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newTemplateItem(template))
+}
+
+func (h *BotConfigHandler) UpdateTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+	var req updateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	if req.Name == nil && req.Description == nil && len(req.Config) == 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: service.ErrNoFieldsToUpdate.Error()})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(c.Request.Context(), id, req.Name, req.Description, req.Config, authCtx.UID)
+	if err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTemplateItem(template))
+}
+
+func (h *BotConfigHandler) DeleteTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTemplate(c.Request.Context(), id); err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func (h *BotConfigHandler) AssignTemplate(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+	var req assignTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	assigned, err := h.service.AssignTemplate(c.Request.Context(), id, req.IDs, authCtx.UID)
+	if err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"assigned": assigned})
+}
+
+func (h *BotConfigHandler) Current(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	cfg, err := h.service.Current(c.Request.Context(), id)
+	if err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newPenggunaConfigItem(cfg))
+}
+
+func (h *BotConfigHandler) Set(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	var req setConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	cfg, err := h.service.Set(c.Request.Context(), id, req.Config, req.Note, authCtx.UID)
+	if err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newPenggunaConfigItem(cfg))
+}
+
+func (h *BotConfigHandler) History(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	q, ok := parseQuery(c, repository.PenggunaConfigQuery)
+	if !ok {
+		return
+	}
+
+	history, page, err := h.service.History(c.Request.Context(), id, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]penggunaConfigItem, 0, len(history))
+	for _, cfg := range history {
+		items = append(items, newPenggunaConfigItem(cfg))
+	}
+	c.JSON(http.StatusOK, queryspec.NewResponse(items, page, q))
+}
+
+func (h *BotConfigHandler) Rollback(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	var req rollbackConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	if req.Version <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_version"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	cfg, err := h.service.Rollback(c.Request.Context(), id, req.Version, req.Note, authCtx.UID)
+	if err != nil {
+		writeBotConfigError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newPenggunaConfigItem(cfg))
+}
+
+func parseTemplateID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("template_id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_template_id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeBotConfigError(c *gin.Context, err error) {
+	var validationErr *botconfig.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": botconfig.ErrInvalidConfig.Error(), "problems": validationErr.Problems})
+	case errors.Is(err, service.ErrTemplateNameRequired),
+		errors.Is(err, service.ErrBulkNoTarget),
+		errors.Is(err, service.ErrBulkTooManyIDs):
+		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, repository.ErrTemplateNameConflict):
+		c.JSON(http.StatusConflict, errorResponse{Error: "template_name_exists"})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+	}
+}
+
+func newTemplateItem(template model.BotConfigTemplate) templateItem {
+	return templateItem{
+		ID:          template.ID,
+		Name:        template.Name,
+		Description: template.Description,
+		Config:      template.Config,
+		CreatedAt:   template.CreatedAt,
+		CreatedBy:   template.CreatedBy,
+		UpdatedAt:   template.UpdatedAt,
+		UpdatedBy:   template.UpdatedBy,
+	}
+}
+
+func newPenggunaConfigItem(cfg model.PenggunaConfig) penggunaConfigItem {
+	return penggunaConfigItem{
+		Version:    cfg.Version,
+		Config:     cfg.Config,
+		Source:     cfg.Source,
+		TemplateID: cfg.TemplateID,
+		RollbackOf: cfg.RollbackOf,
+		Note:       cfg.Note,
+		CreatedBy:  cfg.CreatedBy,
+		CreatedAt:  cfg.CreatedAt,
+		ETag:       service.ConfigETag(cfg),
+	}
+}
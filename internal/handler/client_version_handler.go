@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/service"
+)
+
+type ClientVersionHandler struct {
+	service *service.ClientVersionService
+}
+
+func NewClientVersionHandler(service *service.ClientVersionService) *ClientVersionHandler {
+	return &ClientVersionHandler{service: service}
+}
+
+type setClientVersionRequest struct {
+	MinVersion         string `json:"min_version"`
+	RecommendedVersion string `json:"recommended_version"`
+	DownloadURL        string `json:"download_url"`
+}
+
+type clientVersionItem struct {
+	DeviceType         string    `json:"device_type"`
+	MinVersion         string    `json:"min_version"`
+	RecommendedVersion string    `json:"recommended_version"`
+	DownloadURL        string    `json:"download_url"`
+	UpdatedAt          time.Time `json:"updated_at"`
+	UpdatedBy          string    `json:"updated_by"`
+}
+
+func (h *ClientVersionHandler) List(c *gin.Context) {
+	policies, err := h.service.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]clientVersionItem, 0, len(policies))
+	for _, policy := range policies {
+		items = append(items, newClientVersionItem(policy))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+func (h *ClientVersionHandler) Set(c *gin.Context) {
+	var req setClientVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	policy, err := h.service.Set(c.Request.Context(), model.ClientVersionPolicy{
+		DeviceType:         c.Param("device_type"),
+		MinVersion:         req.MinVersion,
+		RecommendedVersion: req.RecommendedVersion,
+		DownloadURL:        req.DownloadURL,
+		UpdatedBy:          authCtx.UID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDeviceTypeRequired),
+			errors.Is(err, service.ErrInvalidVersion),
+			errors.Is(err, service.ErrVersionOrder),
+			errors.Is(err, service.ErrInvalidDownloadURL):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newClientVersionItem(policy))
+}
+
+func (h *ClientVersionHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("device_type")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func newClientVersionItem(policy model.ClientVersionPolicy) clientVersionItem {
+	return clientVersionItem{
+		DeviceType:         policy.DeviceType,
+		MinVersion:         policy.MinVersion,
+		RecommendedVersion: policy.RecommendedVersion,
+		DownloadURL:        policy.DownloadURL,
+		UpdatedAt:          policy.UpdatedAt,
+		UpdatedBy:          policy.UpdatedBy,
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/service"
+)
+
+const defaultDashboardDays = 30
+
+type DashboardHandler struct {
+	dashboard *service.DashboardService
+}
+
+func NewDashboardHandler(dashboard *service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{dashboard: dashboard}
+}
+
+type dashboardGroup struct {
+	Key    string `json:"key"`
+	Total  int    `json:"total"`
+	Active int    `json:"active"`
+}
+
+type dashboardPoint struct {
+	Bucket        time.Time `json:"bucket"`
+	Registrations int       `json:"registrations"`
+	Activations   int       `json:"activations"`
+	Logins        int       `json:"logins"`
+}
+
+func (h *DashboardHandler) Summary(c *gin.Context) {
+	summary, err := h.dashboard.Summary(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":    summary.Total,
+		"active":   summary.Active,
+		"inactive": summary.Inactive,
+		"balance": gin.H{
+			"total":   summary.TotalBalance,
+			"average": summary.AvgBalance,
+		},
+		"deposits_sum": gin.H{
+			"total":   summary.TotalDeposit,
+			"average": summary.AvgDeposit,
+		},
+	})
+}
+
+func (h *DashboardHandler) Breakdown(c *gin.Context) {
+	from, to, ok := parseDateRange(c, time.Time{})
+	if !ok {
+		return
+	}
+
+	breakdown, err := h.dashboard.Breakdown(c.Request.Context(), from, to)
+	if err != nil {
+		writeDashboardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jenis":   toDashboardGroups(breakdown.Jenis),
+		"country": toDashboardGroups(breakdown.Country),
+	})
+}
+
+func (h *DashboardHandler) Timeseries(c *gin.Context) {
+	from, to, ok := parseDateRange(c, time.Now().UTC().AddDate(0, 0, -defaultDashboardDays))
+	if !ok {
+		return
+	}
+	bucket := c.DefaultQuery("bucket", service.BucketDay)
+
+	points, err := h.dashboard.Timeseries(c.Request.Context(), from, to, bucket)
+	if err != nil {
+		writeDashboardError(c, err)
+		return
+	}
+
+	items := make([]dashboardPoint, 0, len(points))
+	for _, point := range points {
+		items = append(items, dashboardPoint{
+			Bucket:        point.Bucket,
+			Registrations: point.Registrations,
+			Activations:   point.Activations,
+			Logins:        point.Logins,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":   from,
+		"to":     to,
+		"bucket": bucket,
+		"data":   items,
+	})
+}
+
+func parseDateRange(c *gin.Context, defaultFrom time.Time) (time.Time, time.Time, bool) {
+	from := defaultFrom.Truncate(24 * time.Hour)
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_from"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_to"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	return from, to, true
+}
+
+func writeDashboardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBucket):
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_bucket"})
+	case errors.Is(err, service.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_range"})
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+	}
+}
+
+func toDashboardGroups(groups []model.DashboardGroup) []dashboardGroup {
+	items := make([]dashboardGroup, 0, len(groups))
+	for _, group := range groups {
+		items = append(items, dashboardGroup{Key: group.Key, Total: group.Total, Active: group.Active})
+	}
+	return items
+}
@@ -4,17 +4,46 @@ import (
 	"context"
 	"database/sql"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/migrate"
+	"koalbot_api/internal/probe"
+)
+
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+
+	poolSaturationWarn = 0.9
 )
 
 type HealthHandler struct {
-	db *sql.DB
+	db            *sql.DB
+	migration     migrate.Result
+	stockityProbe *probe.Probe
+	shuttingDown  atomic.Bool
+}
+
+func NewHealthHandler(db *sql.DB, migration migrate.Result, stockityProbe *probe.Probe) *HealthHandler {
+	return &HealthHandler{
+		db:            db,
+		migration:     migration,
+		stockityProbe: stockityProbe,
+	}
+}
+
+type healthCheck struct {
+	Status string         `json:"status"`
+	Detail map[string]any `json:"detail,omitempty"`
 }
 
-func NewHealthHandler(db *sql.DB) *HealthHandler {
-	return &HealthHandler{db: db}
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks"`
 }
 
 func (h *HealthHandler) Health(c *gin.Context) {
@@ -28,3 +57,103 @@ func (h *HealthHandler) Health(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
+
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *HealthHandler) Ready(c *gin.Context) {
+	checks := map[string]healthCheck{
+		"shutdown":  h.checkShutdown(),
+		"database":  h.checkDatabase(c.Request.Context()),
+		"migration": h.checkMigration(),
+		"db_pool":   h.checkPool(),
+		"stockity":  h.checkStockity(),
+	}
+
+	status := "ready"
+	code := http.StatusOK
+	for _, check := range checks {
+		if check.Status == checkFail {
+			status = "not_ready"
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	c.JSON(code, readinessResponse{Status: status, Checks: checks})
+}
+
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *HealthHandler) checkShutdown() healthCheck {
+	if h.shuttingDown.Load() {
+		return healthCheck{Status: checkFail, Detail: map[string]any{"reason": "shutting_down"}}
+	}
+	return healthCheck{Status: checkOK}
+}
+
+func (h *HealthHandler) checkDatabase(ctx context.Context) healthCheck {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := h.db.PingContext(ctx); err != nil {
+		return healthCheck{Status: checkFail, Detail: map[string]any{"error": err.Error()}}
+	}
+	return healthCheck{Status: checkOK, Detail: map[string]any{"latency_ms": time.Since(start).Milliseconds()}}
+}
+
+func (h *HealthHandler) checkMigration() healthCheck {
+	if h.migration.AppliedAt.IsZero() {
+		return healthCheck{Status: checkFail, Detail: map[string]any{"reason": "not_applied"}}
+	}
+	return healthCheck{Status: checkOK, Detail: map[string]any{
+		"path":       h.migration.Path,
+		"applied_at": h.migration.AppliedAt,
+	}}
+}
+
+func (h *HealthHandler) checkPool() healthCheck {
+	stats := h.db.Stats()
+	detail := map[string]any{
+		"open":       stats.OpenConnections,
+		"in_use":     stats.InUse,
+		"idle":       stats.Idle,
+		"max_open":   stats.MaxOpenConnections,
+		"wait_count": stats.WaitCount,
+	}
+	if stats.MaxOpenConnections <= 0 {
+		return healthCheck{Status: checkOK, Detail: detail}
+	}
+
+	saturation := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+	detail["saturation"] = saturation
+	if saturation >= poolSaturationWarn {
+		return healthCheck{Status: checkWarn, Detail: detail}
+	}
+	return healthCheck{Status: checkOK, Detail: detail}
+}
+
+func (h *HealthHandler) checkStockity() healthCheck {
+	if h.stockityProbe == nil {
+		return healthCheck{Status: checkWarn, Detail: map[string]any{"reason": "probe_disabled"}}
+	}
+
+	result, ok := h.stockityProbe.Last()
+	if !ok {
+		return healthCheck{Status: checkWarn, Detail: map[string]any{"reason": "not_probed_yet"}}
+	}
+
+	detail := map[string]any{
+		"checked_at": result.CheckedAt,
+		"latency_ms": result.Latency.Milliseconds(),
+	}
+	if !result.OK {
+		detail["error"] = result.Error
+		return healthCheck{Status: checkWarn, Detail: detail}
+	}
+	return healthCheck{Status: checkOK, Detail: detail}
+}
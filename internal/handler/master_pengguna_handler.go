@@ -2,16 +2,21 @@ package handler
 
 import (
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
-	"koalbot_api/internal/pagination"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
 	"koalbot_api/internal/repository"
 	"koalbot_api/internal/service"
+	"koalbot_api/internal/spreadsheet"
 )
 
 type MasterPenggunaHandler struct {
@@ -30,22 +35,66 @@ type createMasterPenggunaRequest struct {
 }
 
 type updateMasterPenggunaRequest struct {
-	IDPengguna *int64  `json:"id_pengguna"`
+	IDPengguna  *int64          `json:"id_pengguna"`
+	Telegram    *string         `json:"telegram"`
+	Jenis       *string         `json:"jenis"`
+	Active      *bool           `json:"active"`
+	ActiveUntil json.RawMessage `json:"active_until"`
+}
+
+type bulkMasterPenggunaRequest struct {
+	Action  string                    `json:"action"`
+	IDs     []int64                   `json:"ids"`
+	Filter  *bulkMasterPenggunaFilter `json:"filter"`
+	Confirm int                       `json:"confirm"`
+}
+
+type bulkMasterPenggunaFilter struct {
+	Search string `json:"search"`
+	Jenis  string `json:"jenis"`
+}
+
+type suggestItem struct {
+	ID         int64   `json:"id"`
+	IDPengguna int64   `json:"id_pengguna"`
 	Telegram   *string `json:"telegram"`
-	Jenis      *string `json:"jenis"`
-	Active     *bool   `json:"active"`
+	Jenis      string  `json:"jenis"`
+	Active     bool    `json:"active"`
+	Name       string  `json:"name,omitempty"`
+	Nickname   string  `json:"nickname,omitempty"`
+	Email      string  `json:"email,omitempty"`
+}
+
+type bulkResultItem struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
 }
 
 type masterPenggunaItem struct {
-	ID         int64      `json:"id"`
-	UUID       string     `json:"uuid"`
-	IDPengguna int64      `json:"id_pengguna"`
-	Telegram   *string    `json:"telegram"`
-	Jenis      string     `json:"jenis"`
-	Active     bool       `json:"active"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  *time.Time `json:"updated_at"`
-	DeletedAt  *time.Time `json:"deleted_at"`
+	ID          int64      `json:"id"`
+	UUID        string     `json:"uuid"`
+	IDPengguna  int64      `json:"id_pengguna"`
+	Telegram    *string    `json:"telegram"`
+	Jenis       string     `json:"jenis"`
+	Active      bool       `json:"active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	ActiveUntil *time.Time `json:"active_until"`
+
+	Detail *masterPenggunaDetail `json:"detail,omitempty"`
+}
+
+type masterPenggunaDetail struct {
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Nickname  string  `json:"nickname"`
+	Email     string  `json:"email"`
+	Balance   float64 `json:"balance"`
+	Currency  string  `json:"currency"`
+	Country   string  `json:"country"`
+	Blocked   bool    `json:"blocked"`
 }
 
 func (h *MasterPenggunaHandler) Create(c *gin.Context) {
@@ -75,6 +124,10 @@ func (h *MasterPenggunaHandler) Create(c *gin.Context) {
 
 	item, err := h.service.Create(c.Request.Context(), req.IDPengguna, req.Telegram, jenis, active)
 	if err != nil {
+		if errors.Is(err, repository.ErrIDPenggunaConflict) {
+			c.JSON(http.StatusConflict, errorResponse{Error: "id_pengguna_exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 		return
 	}
@@ -109,23 +162,12 @@ func (h *MasterPenggunaHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, masterPenggunaItem{
-		ID:         item.ID,
-		UUID:       item.UUID,
-		IDPengguna: item.IDPengguna,
-		Telegram:   item.Telegram,
-		Jenis:      item.Jenis,
-		Active:     item.Active,
-		CreatedAt:  item.CreatedAt,
-		UpdatedAt:  item.UpdatedAt,
-		DeletedAt:  item.DeletedAt,
-	})
+	c.JSON(http.StatusOK, newMasterPenggunaItem(item, includesDetail(c)))
 }
 
 func (h *MasterPenggunaHandler) List(c *gin.Context) {
-	params, err := pagination.Parse(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_pagination"})
+	q, ok := parseQuery(c, repository.MasterPenggunaQuery)
+	if !ok {
 		return
 	}
 	jenis := c.Query("jenis")
@@ -134,28 +176,52 @@ func (h *MasterPenggunaHandler) List(c *gin.Context) {
 		return
 	}
 
-	items, total, err := h.service.List(c.Request.Context(), params.Search, jenis, params.Limit, params.Offset)
+	items, page, err := h.service.List(c.Request.Context(), q, jenis, c.Query("deleted") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 		return
 	}
 
+	includeDetail := includesDetail(c)
 	responseItems := make([]masterPenggunaItem, 0, len(items))
 	for _, item := range items {
-		responseItems = append(responseItems, masterPenggunaItem{
+		responseItems = append(responseItems, newMasterPenggunaItem(item, includeDetail))
+	}
+
+	c.JSON(http.StatusOK, queryspec.NewResponse(responseItems, page, q))
+}
+
+func (h *MasterPenggunaHandler) Suggest(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_limit"})
+		return
+	}
+
+	items, err := h.service.Suggest(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	suggestions := make([]suggestItem, 0, len(items))
+	for _, item := range items {
+		suggestion := suggestItem{
 			ID:         item.ID,
-			UUID:       item.UUID,
 			IDPengguna: item.IDPengguna,
 			Telegram:   item.Telegram,
 			Jenis:      item.Jenis,
 			Active:     item.Active,
-			CreatedAt:  item.CreatedAt,
-			UpdatedAt:  item.UpdatedAt,
-			DeletedAt:  item.DeletedAt,
-		})
+		}
+		if item.Detail != nil {
+			suggestion.Name = strings.TrimSpace(item.Detail.FirstName + " " + item.Detail.LastName)
+			suggestion.Nickname = item.Detail.Nickname
+			suggestion.Email = item.Detail.Email
+		}
+		suggestions = append(suggestions, suggestion)
 	}
 
-	c.JSON(http.StatusOK, pagination.NewResponse(responseItems, total, params))
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
 }
 
 func (h *MasterPenggunaHandler) Update(c *gin.Context) {
@@ -177,6 +243,22 @@ func (h *MasterPenggunaHandler) Update(c *gin.Context) {
 		Jenis:      req.Jenis,
 		Active:     req.Active,
 	}
+	if len(req.ActiveUntil) > 0 {
+		if string(req.ActiveUntil) == "null" {
+			update.ClearActiveUntil = true
+		} else {
+			var activeUntil time.Time
+			if err := json.Unmarshal(req.ActiveUntil, &activeUntil); err != nil {
+				c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_active_until"})
+				return
+			}
+			update.ActiveUntil = &activeUntil
+		}
+	}
+	if req.Active != nil && *req.Active && update.ActiveUntil != nil && !update.ActiveUntil.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "active_until_in_past"})
+		return
+	}
 	if req.Jenis != nil && !isValidJenis(*req.Jenis) {
 		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_jenis"})
 		return
@@ -186,6 +268,8 @@ func (h *MasterPenggunaHandler) Update(c *gin.Context) {
 		switch {
 		case errors.Is(err, service.ErrMasterPenggunaNoFields):
 			c.JSON(http.StatusBadRequest, errorResponse{Error: "no_fields_to_update"})
+		case errors.Is(err, repository.ErrIDPenggunaConflict):
+			c.JSON(http.StatusConflict, errorResponse{Error: "id_pengguna_exists"})
 		default:
 			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 		}
@@ -210,20 +294,203 @@ func (h *MasterPenggunaHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
 }
 
-func isValidJenis(val string) bool {
-	return val == "stockity" || val == "binomo" || val == "olymptrade"
+func (h *MasterPenggunaHandler) Restore(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	if err := h.service.Restore(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+		case errors.Is(err, repository.ErrIDPenggunaConflict):
+			c.JSON(http.StatusConflict, errorResponse{Error: "id_pengguna_exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "restored"})
 }
 
-func (h *MasterPenggunaHandler) Summary(c *gin.Context) {
-	activeCount, inactiveCount, err := h.service.Summary(c.Request.Context())
+func (h *MasterPenggunaHandler) Import(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "file_required"})
+		return
+	}
+	defer file.Close()
+
+	records, err := spreadsheet.Read(header.Filename, file)
+	if err != nil {
+		if errors.Is(err, spreadsheet.ErrUnsupportedFormat) {
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "unsupported_format"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_file"})
 		return
 	}
 
+	dryRun := c.Query("dry_run") == "true"
+	report, err := h.service.Import(c.Request.Context(), records, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportInvalidRows):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_rows", "report": report})
+		case errors.Is(err, service.ErrImportEmpty):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "empty_file"})
+		case errors.Is(err, service.ErrImportMissingColumn):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "missing_id_pengguna_column"})
+		case errors.Is(err, service.ErrImportTooManyRows):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "too_many_rows"})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *MasterPenggunaHandler) Export(c *gin.Context) {
+	jenis := c.Query("jenis")
+	if jenis != "" && !isValidJenis(jenis) {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_jenis"})
+		return
+	}
+
+	filename := "master-pengguna-" + time.Now().UTC().Format("20060102-150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	_ = writer.Write([]string{"id", "uuid", "id_pengguna", "telegram", "jenis", "active", "created_at", "updated_at"})
+
+	err := h.service.Export(c.Request.Context(), c.Query("search"), jenis, func(item model.MasterPengguna) error {
+		telegram := ""
+		if item.Telegram != nil {
+			telegram = *item.Telegram
+		}
+		updatedAt := ""
+		if item.UpdatedAt != nil {
+			updatedAt = item.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		return writer.Write([]string{
+			strconv.FormatInt(item.ID, 10),
+			item.UUID,
+			strconv.FormatInt(item.IDPengguna, 10),
+			csvText(telegram),
+			item.Jenis,
+			strconv.FormatBool(item.Active),
+			item.CreatedAt.UTC().Format(time.RFC3339),
+			updatedAt,
+		})
+	})
+	writer.Flush()
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func csvText(val string) string {
+	if val != "" && strings.ContainsRune("=+-@\t\r", rune(val[0])) {
+		return "'" + val
+	}
+	return val
+}
+
+func (h *MasterPenggunaHandler) Bulk(c *gin.Context) {
+	var req bulkMasterPenggunaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+
+	var filter *repository.BulkMasterPenggunaFilter
+	if req.Filter != nil {
+		if req.Filter.Jenis != "" && !isValidJenis(req.Filter.Jenis) {
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_jenis"})
+			return
+		}
+		filter = &repository.BulkMasterPenggunaFilter{Search: req.Filter.Search, Jenis: req.Filter.Jenis}
+	}
+
+	results, matched, err := h.service.Bulk(c.Request.Context(), req.Action, req.IDs, filter, req.Confirm)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBulkInvalidAction):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_action"})
+		case errors.Is(err, service.ErrBulkNoTarget):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "ids_or_filter_required"})
+		case errors.Is(err, service.ErrBulkTooManyIDs):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "too_many_ids"})
+		case errors.Is(err, service.ErrBulkTooLarge):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bulk_too_large", "matched": matched})
+		case errors.Is(err, service.ErrBulkConfirmationRequired):
+			c.JSON(http.StatusConflict, gin.H{"error": "confirmation_required", "matched": matched})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	items := make([]bulkResultItem, 0, len(results))
+	counts := map[string]int{}
+	for _, result := range results {
+		items = append(items, bulkResultItem{ID: result.ID, Status: result.Status})
+		counts[result.Status]++
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"total":    activeCount + inactiveCount,
-		"active":   activeCount,
-		"inactive": inactiveCount,
+		"action":  req.Action,
+		"matched": matched,
+		"summary": counts,
+		"results": items,
 	})
 }
+
+func isValidJenis(val string) bool {
+	return service.IsValidJenis(val)
+}
+
+func newMasterPenggunaItem(item model.MasterPengguna, includeDetail bool) masterPenggunaItem {
+	result := masterPenggunaItem{
+		ID:          item.ID,
+		UUID:        item.UUID,
+		IDPengguna:  item.IDPengguna,
+		Telegram:    item.Telegram,
+		Jenis:       item.Jenis,
+		Active:      item.Active,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		DeletedAt:   item.DeletedAt,
+		LastLoginAt: item.LastLoginAt,
+		ActiveUntil: item.ActiveUntil,
+	}
+	if includeDetail && item.Detail != nil {
+		result.Detail = &masterPenggunaDetail{
+			FirstName: item.Detail.FirstName,
+			LastName:  item.Detail.LastName,
+			Nickname:  item.Detail.Nickname,
+			Email:     item.Detail.Email,
+			Balance:   item.Detail.Balance,
+			Currency:  item.Detail.Currency,
+			Country:   item.Detail.Country,
+			Blocked:   item.Detail.Blocked,
+		}
+	}
+	return result
+}
+
+func includesDetail(c *gin.Context) bool {
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		if strings.TrimSpace(include) == "detail" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/payment"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+type PaymentHandler struct {
+	service *service.PaymentService
+}
+
+func NewPaymentHandler(service *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+type paymentItem struct {
+	ID                int64           `json:"id"`
+	Provider          string          `json:"provider"`
+	ProviderPaymentID string          `json:"provider_payment_id"`
+	Reference         string          `json:"reference"`
+	PenggunaID        *int64          `json:"pengguna_id"`
+	IDPengguna        int64           `json:"id_pengguna"`
+	Amount            float64         `json:"amount"`
+	Currency          string          `json:"currency"`
+	PeriodDays        int             `json:"period_days"`
+	ActiveUntil       *time.Time      `json:"active_until"`
+	PaidAt            time.Time       `json:"paid_at"`
+	Payload           json.RawMessage `json:"payload,omitempty"`
+	ReceivedAt        time.Time       `json:"received_at"`
+}
+
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_body"})
+		return
+	}
+
+	applied, status, err := h.service.Receive(c.Request.Context(), c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownProvider):
+			c.JSON(http.StatusNotFound, errorResponse{Error: err.Error()})
+		case errors.Is(err, payment.ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, errorResponse{Error: err.Error()})
+		case errors.Is(err, payment.ErrInvalidPayload):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	if status == model.PaymentIgnored {
+		c.JSON(http.StatusOK, gin.H{"status": status})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":       status,
+		"payment_id":   applied.ID,
+		"id_pengguna":  applied.IDPengguna,
+		"active_until": applied.ActiveUntil,
+	})
+}
+
+func (h *PaymentHandler) List(c *gin.Context) {
+	q, ok := parseQuery(c, repository.PaymentQuery)
+	if !ok {
+		return
+	}
+
+	payments, page, err := h.service.List(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]paymentItem, 0, len(payments))
+	for _, p := range payments {
+		items = append(items, paymentItem{
+			ID:                p.ID,
+			Provider:          p.Provider,
+			ProviderPaymentID: p.ProviderPaymentID,
+			Reference:         p.Reference,
+			PenggunaID:        p.PenggunaID,
+			IDPengguna:        p.IDPengguna,
+			Amount:            p.Amount,
+			Currency:          p.Currency,
+			PeriodDays:        p.PeriodDays,
+			ActiveUntil:       p.ActiveUntil,
+			PaidAt:            p.PaidAt,
+			Payload:           p.Payload,
+			ReceivedAt:        p.ReceivedAt,
+		})
+	}
+	c.JSON(http.StatusOK, queryspec.NewResponse(items, page, q))
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+type PenggunaCommandHandler struct {
+	service *service.CommandService
+}
+
+func NewPenggunaCommandHandler(service *service.CommandService) *PenggunaCommandHandler {
+	return &PenggunaCommandHandler{service: service}
+}
+
+type enqueueCommandRequest struct {
+	Command string          `json:"command"`
+	Payload json.RawMessage `json:"payload"`
+	TTLSec  int             `json:"ttl_sec"`
+}
+
+type commandItem struct {
+	ID          int64           `json:"id"`
+	Command     string          `json:"command"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	DeviceID    string          `json:"device_id,omitempty"`
+	Attempts    int             `json:"attempts"`
+	Result      string          `json:"result,omitempty"`
+	CreatedBy   string          `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	DeliveredAt *time.Time      `json:"delivered_at"`
+	AckedAt     *time.Time      `json:"acked_at"`
+}
+
+func (h *PenggunaCommandHandler) Enqueue(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	var req enqueueCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	if string(req.Payload) == "null" {
+		req.Payload = nil
+	}
+
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	cmd, err := h.service.Enqueue(c.Request.Context(), id, req.Command, req.Payload, time.Duration(req.TTLSec)*time.Second, authCtx.UID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCommand), errors.Is(err, service.ErrInvalidTTL):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, newCommandItem(cmd))
+}
+
+func (h *PenggunaCommandHandler) List(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	q, ok := parseQuery(c, repository.PenggunaCommandQuery)
+	if !ok {
+		return
+	}
+
+	commands, page, err := h.service.List(c.Request.Context(), id, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, queryspec.NewResponse(newCommandItems(commands), page, q))
+}
+
+func (h *PenggunaCommandHandler) Cancel(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	commandID, err := strconv.ParseInt(c.Param("command_id"), 10, 64)
+	if err != nil || commandID <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_command_id"})
+		return
+	}
+
+	if err := h.service.Cancel(c.Request.Context(), id, commandID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": model.CommandCancelled})
+}
+
+func newCommandItem(cmd model.PenggunaCommand) commandItem {
+	payload := cmd.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage("null")
+	}
+	return commandItem{
+		ID:          cmd.ID,
+		Command:     cmd.Command,
+		Payload:     payload,
+		Status:      cmd.Status,
+		DeviceID:    cmd.DeviceID,
+		Attempts:    cmd.Attempts,
+		Result:      cmd.Result,
+		CreatedBy:   cmd.CreatedBy,
+		CreatedAt:   cmd.CreatedAt,
+		ExpiresAt:   cmd.ExpiresAt,
+		DeliveredAt: cmd.DeliveredAt,
+		AckedAt:     cmd.AckedAt,
+	}
+}
+
+func newCommandItems(commands []model.PenggunaCommand) []commandItem {
+	items := make([]commandItem, 0, len(commands))
+	for _, cmd := range commands {
+		items = append(items, newCommandItem(cmd))
+	}
+	return items
+}
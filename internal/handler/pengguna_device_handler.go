@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/service"
+)
+
+type PenggunaDeviceHandler struct {
+	service *service.DeviceService
+}
+
+func NewPenggunaDeviceHandler(service *service.DeviceService) *PenggunaDeviceHandler {
+	return &PenggunaDeviceHandler{service: service}
+}
+
+type penggunaDeviceItem struct {
+	DeviceID   string    `json:"device_id"`
+	DeviceType string    `json:"device_type"`
+	LastIP     string    `json:"last_ip"`
+	BoundAt    time.Time `json:"bound_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func (h *PenggunaDeviceHandler) List(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	devices, err := h.service.List(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]penggunaDeviceItem, 0, len(devices))
+	for _, device := range devices {
+		items = append(items, penggunaDeviceItem{
+			DeviceID:   device.DeviceID,
+			DeviceType: device.DeviceType,
+			LastIP:     device.LastIP,
+			BoundAt:    device.BoundAt,
+			LastSeenAt: device.LastSeenAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        items,
+		"max_devices": h.service.Limit(),
+	})
+}
+
+func (h *PenggunaDeviceHandler) Unbind(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	if err := h.service.Unbind(c.Request.Context(), id, c.Param("device_id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "unbound"})
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+type PenggunaLoginHandler struct {
+	service *service.LoginAuditService
+}
+
+func NewPenggunaLoginHandler(service *service.LoginAuditService) *PenggunaLoginHandler {
+	return &PenggunaLoginHandler{service: service}
+}
+
+type penggunaLoginItem struct {
+	ID         int64     `json:"id"`
+	IDPengguna *int64    `json:"id_pengguna"`
+	Email      string    `json:"email"`
+	Outcome    string    `json:"outcome"`
+	DeviceID   string    `json:"device_id"`
+	DeviceType string    `json:"device_type"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type penggunaAlertItem struct {
+	ID         int64           `json:"id"`
+	PenggunaID int64           `json:"pengguna_id"`
+	IDPengguna int64           `json:"id_pengguna"`
+	Kind       string          `json:"kind"`
+	Detail     json.RawMessage `json:"detail"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func (h *PenggunaLoginHandler) List(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	q, ok := parseQuery(c, repository.PenggunaLoginQuery)
+	if !ok {
+		return
+	}
+
+	logins, page, err := h.service.List(c.Request.Context(), id, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]penggunaLoginItem, 0, len(logins))
+	for _, login := range logins {
+		items = append(items, penggunaLoginItem{
+			ID:         login.ID,
+			IDPengguna: login.IDPengguna,
+			Email:      login.Email,
+			Outcome:    login.Outcome,
+			DeviceID:   login.DeviceID,
+			DeviceType: login.DeviceType,
+			IP:         login.IP,
+			CreatedAt:  login.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, queryspec.NewResponse(items, page, q))
+}
+
+func (h *PenggunaLoginHandler) Alerts(c *gin.Context) {
+	q, ok := parseQuery(c, repository.PenggunaAlertQuery)
+	if !ok {
+		return
+	}
+
+	alerts, page, err := h.service.Alerts(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]penggunaAlertItem, 0, len(alerts))
+	for _, alert := range alerts {
+		detail := alert.Detail
+		if len(detail) == 0 {
+			detail = json.RawMessage("null")
+		}
+		items = append(items, penggunaAlertItem{
+			ID:         alert.ID,
+			PenggunaID: alert.PenggunaID,
+			IDPengguna: alert.IDPengguna,
+			Kind:       alert.Kind,
+			Detail:     detail,
+			CreatedAt:  alert.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, queryspec.NewResponse(items, page, q))
+}
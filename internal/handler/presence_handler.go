@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/presence"
+	"koalbot_api/internal/repository"
+)
+
+type PresenceHandler struct {
+	registry *presence.Registry
+	repo     *repository.PenggunaPresenceRepository
+}
+
+func NewPresenceHandler(registry *presence.Registry, repo *repository.PenggunaPresenceRepository) *PresenceHandler {
+	return &PresenceHandler{registry: registry, repo: repo}
+}
+
+type presenceItem struct {
+	PenggunaID    int64     `json:"pengguna_id"`
+	IDPengguna    int64     `json:"id_pengguna"`
+	Online        bool      `json:"online"`
+	DeviceID      string    `json:"device_id"`
+	DeviceType    string    `json:"device_type"`
+	ClientVersion string    `json:"client_version"`
+	Running       bool      `json:"running"`
+	State         string    `json:"state"`
+	IP            string    `json:"ip"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+func (h *PresenceHandler) Online(c *gin.Context) {
+	online := h.registry.Online()
+	items := make([]presenceItem, 0, len(online))
+	for _, p := range online {
+		items = append(items, newPresenceItem(p, true))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": len(items),
+	})
+}
+
+func (h *PresenceHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	if p, ok := h.registry.Get(id); ok {
+		c.JSON(http.StatusOK, newPresenceItem(p, true))
+		return
+	}
+
+	p, err := h.repo.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPresenceItem(p, false))
+}
+
+func (h *PresenceHandler) Stream(c *gin.Context) {
+	flusher, ok := startEventStream(c)
+	if !ok {
+		return
+	}
+
+	events, unsubscribe := h.registry.Subscribe()
+	defer unsubscribe()
+
+	online := h.registry.Online()
+	snapshot := make([]presenceItem, 0, len(online))
+	for _, p := range online {
+		snapshot = append(snapshot, newPresenceItem(p, true))
+	}
+	writeEvent(c.Writer, "snapshot", snapshot)
+	flusher.Flush()
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event := <-events:
+			extendWriteDeadline(c, streamWriteTimeout)
+			writeEvent(c.Writer, event.Type, newPresenceItem(event.Presence, event.Type != presence.EventOffline))
+			flusher.Flush()
+		case t := <-ticker.C:
+			extendWriteDeadline(c, streamWriteTimeout)
+			writeEvent(c.Writer, "ping", gin.H{"ts": t.UTC().Format(time.RFC3339)})
+			flusher.Flush()
+		}
+	}
+}
+
+func newPresenceItem(p model.PenggunaPresence, online bool) presenceItem {
+	return presenceItem{
+		PenggunaID:    p.PenggunaID,
+		IDPengguna:    p.IDPengguna,
+		Online:        online,
+		DeviceID:      p.DeviceID,
+		DeviceType:    p.DeviceType,
+		ClientVersion: p.ClientVersion,
+		Running:       p.Running,
+		State:         p.State,
+		IP:            p.IP,
+		LastSeen:      p.LastSeen,
+	}
+}
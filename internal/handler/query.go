@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/queryspec"
+)
+
+func parseQuery(c *gin.Context, schema *queryspec.Schema) (queryspec.Query, bool) {
+	q, err := queryspec.Parse(c, schema)
+	if err == nil {
+		return q, true
+	}
+
+	code := "invalid_pagination"
+	switch {
+	case errors.Is(err, queryspec.ErrInvalidSort):
+		code = "invalid_sort"
+	case errors.Is(err, queryspec.ErrInvalidFilter):
+		code = "invalid_filter"
+	case errors.Is(err, queryspec.ErrInvalidCursor):
+		code = "invalid_cursor"
+	}
+	c.JSON(http.StatusBadRequest, errorResponse{Error: code})
+	return queryspec.Query{}, false
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/service"
+)
+
+const defaultReportDays = 30
+
+type ReportHandler struct {
+	service *service.ReportService
+}
+
+func NewReportHandler(service *service.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+type performanceStats struct {
+	Trades        int     `json:"trades"`
+	Wins          int     `json:"wins"`
+	Losses        int     `json:"losses"`
+	Draws         int     `json:"draws"`
+	WinRate       float64 `json:"win_rate"`
+	Volume        float64 `json:"volume"`
+	Profit        float64 `json:"profit"`
+	MaxDrawdown   float64 `json:"max_drawdown"`
+	MaxWinStreak  int     `json:"max_win_streak"`
+	MaxLossStreak int     `json:"max_loss_streak"`
+	CurrentStreak int     `json:"current_streak"`
+}
+
+type performancePeriod struct {
+	Bucket  time.Time `json:"bucket"`
+	Trades  int       `json:"trades"`
+	Wins    int       `json:"wins"`
+	Losses  int       `json:"losses"`
+	Draws   int       `json:"draws"`
+	WinRate float64   `json:"win_rate"`
+	Volume  float64   `json:"volume"`
+	Profit  float64   `json:"profit"`
+}
+
+type balanceChange struct {
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Change   float64 `json:"change"`
+	Accounts int     `json:"accounts"`
+}
+
+type traderPerformance struct {
+	PenggunaID int64 `json:"pengguna_id"`
+	IDPengguna int64 `json:"id_pengguna"`
+	performanceStats
+}
+
+func (h *ReportHandler) Performance(c *gin.Context) {
+	h.performance(c, 0)
+}
+
+func (h *ReportHandler) PenggunaPerformance(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	h.performance(c, id)
+}
+
+func (h *ReportHandler) performance(c *gin.Context, penggunaID int64) {
+	from, to, ok := parseDateRange(c, time.Now().UTC().AddDate(0, 0, -defaultReportDays))
+	if !ok {
+		return
+	}
+
+	report, err := h.service.Performance(c.Request.Context(), penggunaID, c.DefaultQuery("account_type", "real"), from, to, c.DefaultQuery("period", service.BucketDay))
+	if err != nil {
+		writeReportError(c, err)
+		return
+	}
+
+	periods := make([]performancePeriod, 0, len(report.Periods))
+	for _, p := range report.Periods {
+		periods = append(periods, performancePeriod{
+			Bucket:  p.Bucket,
+			Trades:  p.Trades,
+			Wins:    p.Wins,
+			Losses:  p.Losses,
+			Draws:   p.Draws,
+			WinRate: p.WinRate,
+			Volume:  p.Volume,
+			Profit:  p.Profit,
+		})
+	}
+
+	if c.Query("format") == "csv" {
+		name := "performance"
+		if penggunaID > 0 {
+			name += "-" + strconv.FormatInt(penggunaID, 10)
+		}
+		writer := startCSV(c, name)
+		_ = writer.Write([]string{"bucket", "trades", "wins", "losses", "draws", "win_rate", "volume", "profit"})
+		for _, p := range periods {
+			_ = writer.Write([]string{
+				p.Bucket.Format("2006-01-02"),
+				strconv.Itoa(p.Trades),
+				strconv.Itoa(p.Wins),
+				strconv.Itoa(p.Losses),
+				strconv.Itoa(p.Draws),
+				formatFloat(p.WinRate),
+				formatFloat(p.Volume),
+				formatFloat(p.Profit),
+			})
+		}
+		writer.Flush()
+		return
+	}
+
+	var balance *balanceChange
+	if report.Balance != nil {
+		balance = &balanceChange{
+			Start:    report.Balance.Start,
+			End:      report.Balance.End,
+			Change:   report.Balance.End - report.Balance.Start,
+			Accounts: report.Balance.Accounts,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":         report.From,
+		"to":           report.To,
+		"period":       report.Period,
+		"account_type": report.AccountType,
+		"summary":      newPerformanceStats(report.Summary),
+		"balance":      balance,
+		"data":         periods,
+	})
+}
+
+func (h *ReportHandler) Traders(c *gin.Context) {
+	from, to, ok := parseDateRange(c, time.Now().UTC().AddDate(0, 0, -defaultReportDays))
+	if !ok {
+		return
+	}
+	accountType := c.DefaultQuery("account_type", "real")
+
+	traders, err := h.service.Traders(c.Request.Context(), accountType, from, to)
+	if err != nil {
+		writeReportError(c, err)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writer := startCSV(c, "traders")
+		_ = writer.Write([]string{"pengguna_id", "id_pengguna", "trades", "wins", "losses", "draws", "win_rate", "volume", "profit", "max_drawdown", "max_win_streak", "max_loss_streak", "current_streak"})
+		for _, t := range traders {
+			_ = writer.Write([]string{
+				strconv.FormatInt(t.PenggunaID, 10),
+				strconv.FormatInt(t.IDPengguna, 10),
+				strconv.Itoa(t.Trades),
+				strconv.Itoa(t.Wins),
+				strconv.Itoa(t.Losses),
+				strconv.Itoa(t.Draws),
+				formatFloat(t.WinRate),
+				formatFloat(t.Volume),
+				formatFloat(t.Profit),
+				formatFloat(t.MaxDrawdown),
+				strconv.Itoa(t.MaxWinStreak),
+				strconv.Itoa(t.MaxLossStreak),
+				strconv.Itoa(t.CurrentStreak),
+			})
+		}
+		writer.Flush()
+		return
+	}
+
+	items := make([]traderPerformance, 0, len(traders))
+	for _, t := range traders {
+		items = append(items, traderPerformance{
+			PenggunaID:       t.PenggunaID,
+			IDPengguna:       t.IDPengguna,
+			performanceStats: newPerformanceStats(t.PerformanceStats),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":         from,
+		"to":           to,
+		"account_type": accountType,
+		"data":         items,
+	})
+}
+
+func newPerformanceStats(stats model.PerformanceStats) performanceStats {
+	return performanceStats{
+		Trades:        stats.Trades,
+		Wins:          stats.Wins,
+		Losses:        stats.Losses,
+		Draws:         stats.Draws,
+		WinRate:       stats.WinRate,
+		Volume:        stats.Volume,
+		Profit:        stats.Profit,
+		MaxDrawdown:   stats.MaxDrawdown,
+		MaxWinStreak:  stats.MaxWinStreak,
+		MaxLossStreak: stats.MaxLossStreak,
+		CurrentStreak: stats.CurrentStreak,
+	}
+}
+
+func startCSV(c *gin.Context, name string) *csv.Writer {
+	filename := name + "-" + time.Now().UTC().Format("20060102-150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	return csv.NewWriter(c.Writer)
+}
+
+func formatFloat(val float64) string {
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
+
+func writeReportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAccountType):
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_account_type"})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+	default:
+		writeDashboardError(c, err)
+	}
+}
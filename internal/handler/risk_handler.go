@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+type RiskHandler struct {
+	service *service.RiskService
+}
+
+func NewRiskHandler(service *service.RiskService) *RiskHandler {
+	return &RiskHandler{service: service}
+}
+
+type riskRules struct {
+	MaxDailyLoss     *float64 `json:"max_daily_loss"`
+	MaxTradesPerHour *int     `json:"max_trades_per_hour"`
+	MaxStakePct      *float64 `json:"max_stake_pct"`
+	TradingStart     *string  `json:"trading_start"`
+	TradingEnd       *string  `json:"trading_end"`
+	Timezone         *string  `json:"timezone"`
+}
+
+type riskPlanRequest struct {
+	Name string `json:"name"`
+	riskRules
+}
+
+type setPenggunaRiskRequest struct {
+	PlanID    *int64    `json:"plan_id"`
+	Overrides riskRules `json:"overrides"`
+}
+
+type haltRequest struct {
+	Reason string `json:"reason"`
+}
+
+type riskPlanItem struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy string     `json:"created_by"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	UpdatedBy string     `json:"updated_by"`
+	riskRules
+}
+
+type penggunaRiskItem struct {
+	PenggunaID int64      `json:"pengguna_id"`
+	PlanID     *int64     `json:"plan_id"`
+	PlanName   string     `json:"plan_name,omitempty"`
+	Overrides  riskRules  `json:"overrides"`
+	Effective  riskRules  `json:"effective"`
+	Halted     bool       `json:"halted"`
+	HaltedAt   *time.Time `json:"halted_at"`
+	HaltRule   string     `json:"halt_rule,omitempty"`
+	HaltReason string     `json:"halt_reason,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+	UpdatedBy  string     `json:"updated_by"`
+}
+
+func (h *RiskHandler) ListPlans(c *gin.Context) {
+	plans, err := h.service.ListPlans(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]riskPlanItem, 0, len(plans))
+	for _, plan := range plans {
+		items = append(items, newRiskPlanItem(plan))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+func (h *RiskHandler) GetPlan(c *gin.Context) {
+	id, ok := parsePlanID(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GetPlan(c.Request.Context(), id)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newRiskPlanItem(plan))
+}
+
+func (h *RiskHandler) CreatePlan(c *gin.Context) {
+	var req riskPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	plan, err := h.service.CreatePlan(c.Request.Context(), req.Name, toRiskRules(req.riskRules), authCtx.UID)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newRiskPlanItem(plan))
+}
+
+func (h *RiskHandler) UpdatePlan(c *gin.Context) {
+	id, ok := parsePlanID(c)
+	if !ok {
+		return
+	}
+	var req riskPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	plan, err := h.service.UpdatePlan(c.Request.Context(), id, req.Name, toRiskRules(req.riskRules), authCtx.UID)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newRiskPlanItem(plan))
+}
+
+func (h *RiskHandler) DeletePlan(c *gin.Context) {
+	id, ok := parsePlanID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePlan(c.Request.Context(), id); err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func (h *RiskHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	risk, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newPenggunaRiskItem(risk))
+}
+
+func (h *RiskHandler) Set(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	var req setPenggunaRiskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	risk, err := h.service.Set(c.Request.Context(), id, req.PlanID, toRiskRules(req.Overrides), authCtx.UID)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newPenggunaRiskItem(risk))
+}
+
+func (h *RiskHandler) Halt(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	var req haltRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "halted by " + authCtx.UID
+	}
+	halted, err := h.service.Halt(c.Request.Context(), id, model.RiskRuleManual, reason)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	if !halted {
+		c.JSON(http.StatusConflict, errorResponse{Error: "already_halted"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "halted"})
+}
+
+func (h *RiskHandler) Resume(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	resumed, err := h.service.Resume(c.Request.Context(), id, authCtx.UID)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+	if !resumed {
+		c.JSON(http.StatusConflict, errorResponse{Error: "not_halted"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "resumed"})
+}
+
+func parsePlanID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("plan_id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_plan_id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeRiskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrRiskPlanNameRequired),
+		errors.Is(err, service.ErrRiskPlanNotFound),
+		errors.Is(err, service.ErrInvalidDailyLoss),
+		errors.Is(err, service.ErrInvalidTradesPerHour),
+		errors.Is(err, service.ErrInvalidStakePct),
+		errors.Is(err, service.ErrInvalidTradingHours),
+		errors.Is(err, service.ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, repository.ErrRiskPlanNameConflict):
+		c.JSON(http.StatusConflict, errorResponse{Error: "risk_plan_name_exists"})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+	}
+}
+
+func toRiskRules(rules riskRules) model.RiskRules {
+	return model.RiskRules{
+		MaxDailyLoss:     rules.MaxDailyLoss,
+		MaxTradesPerHour: rules.MaxTradesPerHour,
+		MaxStakePct:      rules.MaxStakePct,
+		TradingStart:     rules.TradingStart,
+		TradingEnd:       rules.TradingEnd,
+		Timezone:         rules.Timezone,
+	}
+}
+
+func newRiskRules(rules model.RiskRules) riskRules {
+	return riskRules{
+		MaxDailyLoss:     rules.MaxDailyLoss,
+		MaxTradesPerHour: rules.MaxTradesPerHour,
+		MaxStakePct:      rules.MaxStakePct,
+		TradingStart:     rules.TradingStart,
+		TradingEnd:       rules.TradingEnd,
+		Timezone:         rules.Timezone,
+	}
+}
+
+func newRiskPlanItem(plan model.RiskPlan) riskPlanItem {
+	return riskPlanItem{
+		ID:        plan.ID,
+		Name:      plan.Name,
+		CreatedAt: plan.CreatedAt,
+		CreatedBy: plan.CreatedBy,
+		UpdatedAt: plan.UpdatedAt,
+		UpdatedBy: plan.UpdatedBy,
+		riskRules: newRiskRules(plan.Rules),
+	}
+}
+
+func newPenggunaRiskItem(risk model.PenggunaRisk) penggunaRiskItem {
+	return penggunaRiskItem{
+		PenggunaID: risk.PenggunaID,
+		PlanID:     risk.PlanID,
+		PlanName:   risk.PlanName,
+		Overrides:  newRiskRules(risk.Overrides),
+		Effective:  newRiskRules(service.EffectiveRiskRules(risk)),
+		Halted:     risk.Halted,
+		HaltedAt:   risk.HaltedAt,
+		HaltRule:   risk.HaltRule,
+		HaltReason: risk.HaltReason,
+		UpdatedAt:  risk.UpdatedAt,
+		UpdatedBy:  risk.UpdatedBy,
+	}
+}
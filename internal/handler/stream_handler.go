@@ -2,12 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const streamWriteTimeout = 30 * time.Second
+
 type StreamHandler struct{}
 
 func NewStreamHandler() *StreamHandler {
@@ -15,15 +18,8 @@ func NewStreamHandler() *StreamHandler {
 }
 
 func (h *StreamHandler) StatusStream(c *gin.Context) {
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("X-Accel-Buffering", "no")
-
-	writer := c.Writer
-	flusher, ok := writer.(http.Flusher)
+	flusher, ok := startEventStream(c)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, errorResponse{Error: "stream_unsupported"})
 		return
 	}
 
@@ -35,12 +31,38 @@ func (h *StreamHandler) StatusStream(c *gin.Context) {
 		case <-c.Request.Context().Done():
 			return
 		case t := <-ticker.C:
-			payload, _ := json.Marshal(gin.H{"ts": t.UTC().Format(time.RFC3339)})
-			_, _ = writer.Write([]byte("event: ping\n"))
-			_, _ = writer.Write([]byte("data: "))
-			_, _ = writer.Write(payload)
-			_, _ = writer.Write([]byte("\n\n"))
+			extendWriteDeadline(c, streamWriteTimeout)
+			writeEvent(c.Writer, "ping", gin.H{"ts": t.UTC().Format(time.RFC3339)})
 			flusher.Flush()
 		}
 	}
 }
+
+func startEventStream(c *gin.Context) (http.Flusher, bool) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "stream_unsupported"})
+		return nil, false
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	extendWriteDeadline(c, streamWriteTimeout)
+	flusher.Flush()
+	return flusher, true
+}
+
+func extendWriteDeadline(c *gin.Context, timeout time.Duration) {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout))
+}
+
+func writeEvent(w io.Writer, event string, data any) {
+	payload, _ := json.Marshal(data)
+	_, _ = w.Write([]byte("event: " + event + "\n"))
+	_, _ = w.Write([]byte("data: "))
+	_, _ = w.Write(payload)
+	_, _ = w.Write([]byte("\n\n"))
+}
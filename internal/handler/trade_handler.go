@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+type TradeHandler struct {
+	service *service.TradeService
+}
+
+func NewTradeHandler(service *service.TradeService) *TradeHandler {
+	return &TradeHandler{service: service}
+}
+
+type tradeItem struct {
+	ID            int64      `json:"id"`
+	PenggunaID    int64      `json:"pengguna_id"`
+	IDPengguna    int64      `json:"id_pengguna"`
+	ClientTradeID string     `json:"client_trade_id"`
+	DeviceID      string     `json:"device_id,omitempty"`
+	Asset         string     `json:"asset"`
+	Direction     string     `json:"direction"`
+	Amount        float64    `json:"amount"`
+	AccountType   string     `json:"account_type"`
+	OpenedAt      time.Time  `json:"open_time"`
+	ClosedAt      *time.Time `json:"close_time"`
+	Result        string     `json:"result,omitempty"`
+	Payout        *float64   `json:"payout"`
+	Profit        *float64   `json:"profit"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+func (h *TradeHandler) List(c *gin.Context) {
+	h.list(c, 0)
+}
+
+func (h *TradeHandler) ListByPengguna(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+	h.list(c, id)
+}
+
+func (h *TradeHandler) list(c *gin.Context, penggunaID int64) {
+	q, ok := parseQuery(c, repository.PenggunaTradeQuery)
+	if !ok {
+		return
+	}
+
+	trades, page, err := h.service.List(c.Request.Context(), penggunaID, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]tradeItem, 0, len(trades))
+	for _, trade := range trades {
+		items = append(items, newTradeItem(trade))
+	}
+
+	c.JSON(http.StatusOK, queryspec.NewResponse(items, page, q))
+}
+
+func newTradeItem(trade model.PenggunaTrade) tradeItem {
+	item := tradeItem{
+		ID:            trade.ID,
+		PenggunaID:    trade.PenggunaID,
+		IDPengguna:    trade.IDPengguna,
+		ClientTradeID: trade.ClientTradeID,
+		DeviceID:      trade.DeviceID,
+		Asset:         trade.Asset,
+		Direction:     trade.Direction,
+		Amount:        trade.Amount,
+		AccountType:   trade.AccountType,
+		OpenedAt:      trade.OpenedAt,
+		ClosedAt:      trade.ClosedAt,
+		Result:        trade.Result,
+		Payout:        trade.Payout,
+		CreatedAt:     trade.CreatedAt,
+		UpdatedAt:     trade.UpdatedAt,
+	}
+	if trade.Payout != nil {
+		profit := *trade.Payout - trade.Amount
+		item.Profit = &profit
+	}
+	return item
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"koalbot_api/internal/middleware"
-	"koalbot_api/internal/pagination"
+	"koalbot_api/internal/queryspec"
 	"koalbot_api/internal/repository"
 	"koalbot_api/internal/service"
 )
@@ -43,6 +43,8 @@ type userItem struct {
 	CreatedBy *string    `json:"created_by"`
 	UpdatedAt *time.Time `json:"updated_at"`
 	UpdatedBy *string    `json:"updated_by"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy *string    `json:"deleted_by,omitempty"`
 }
 
 type registerUserResponse struct {
@@ -83,13 +85,12 @@ func (h *UserHandler) Register(c *gin.Context) {
 }
 
 func (h *UserHandler) List(c *gin.Context) {
-	params, err := pagination.Parse(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_pagination"})
+	q, ok := parseQuery(c, repository.UserQuery)
+	if !ok {
 		return
 	}
 
-	users, total, err := h.users.List(c.Request.Context(), params.Search, params.Limit, params.Offset)
+	users, page, err := h.users.List(c.Request.Context(), q, c.Query("deleted") == "true")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 		return
@@ -106,10 +107,12 @@ func (h *UserHandler) List(c *gin.Context) {
 			CreatedBy: user.CreatedBy,
 			UpdatedAt: user.UpdatedAt,
 			UpdatedBy: user.UpdatedBy,
+			DeletedAt: user.DeletedAt,
+			DeletedBy: user.DeletedBy,
 		})
 	}
 
-	c.JSON(http.StatusOK, pagination.NewResponse(items, total, params))
+	c.JSON(http.StatusOK, queryspec.NewResponse(items, page, q))
 }
 
 func (h *UserHandler) Update(c *gin.Context) {
@@ -173,3 +176,27 @@ func (h *UserHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
 }
+
+func (h *UserHandler) Restore(c *gin.Context) {
+	uid := c.Param("uid")
+	if uid == "" {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "uid_required"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	if err := h.users.Restore(c.Request.Context(), uid, authCtx.UID); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "restored"})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/service"
+)
+
+const maxCommandWait = 30 * time.Second
+
+type V1CommandHandler struct {
+	service *service.CommandService
+}
+
+func NewV1CommandHandler(service *service.CommandService) *V1CommandHandler {
+	return &V1CommandHandler{service: service}
+}
+
+type ackCommandRequest struct {
+	Status string `json:"status"`
+	Result string `json:"result"`
+}
+
+func (h *V1CommandHandler) Poll(c *gin.Context) {
+	session, ok := middleware.GetPenggunaContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	wait := time.Duration(0)
+	if raw := c.Query("wait"); raw != "" {
+		sec, err := strconv.Atoi(raw)
+		if err != nil || sec < 0 {
+			c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_wait"})
+			return
+		}
+		wait = time.Duration(sec) * time.Second
+		if wait > maxCommandWait {
+			wait = maxCommandWait
+		}
+	}
+
+	extendWriteDeadline(c, wait+streamWriteTimeout)
+	commands, err := h.service.Wait(c.Request.Context(), session.PenggunaID, session.DeviceID, wait)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": newCommandItems(commands)})
+}
+
+func (h *V1CommandHandler) Stream(c *gin.Context) {
+	session, ok := middleware.GetPenggunaContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	notify, release := h.service.Subscribe(session.PenggunaID)
+	defer release()
+
+	flusher, ok := startEventStream(c)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	deliver := func() bool {
+		commands, err := h.service.Claim(c.Request.Context(), session.PenggunaID, session.DeviceID)
+		if err != nil {
+			return c.Request.Context().Err() == nil
+		}
+		extendWriteDeadline(c, streamWriteTimeout)
+		for _, cmd := range commands {
+			writeEvent(c.Writer, "command", newCommandItem(cmd))
+		}
+		if len(commands) > 0 {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	if !deliver() {
+		return
+	}
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-notify:
+			if !deliver() {
+				return
+			}
+		case t := <-ticker.C:
+			extendWriteDeadline(c, streamWriteTimeout)
+			writeEvent(c.Writer, "ping", gin.H{"ts": t.UTC().Format(time.RFC3339)})
+			flusher.Flush()
+			if !deliver() {
+				return
+			}
+		}
+	}
+}
+
+func (h *V1CommandHandler) Ack(c *gin.Context) {
+	session, ok := middleware.GetPenggunaContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_id"})
+		return
+	}
+
+	var req ackCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+
+	if err := h.service.Ack(c.Request.Context(), session.PenggunaID, id, req.Status, req.Result); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAckStatus):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": req.Status})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/service"
+)
+
+type V1ConfigHandler struct {
+	service *service.BotConfigService
+	risk    *service.RiskService
+}
+
+func NewV1ConfigHandler(service *service.BotConfigService, risk *service.RiskService) *V1ConfigHandler {
+	return &V1ConfigHandler{service: service, risk: risk}
+}
+
+type haltInfo struct {
+	Rule     string     `json:"rule"`
+	Reason   string     `json:"reason"`
+	HaltedAt *time.Time `json:"halted_at"`
+}
+
+func (h *V1ConfigHandler) Get(c *gin.Context) {
+	session, ok := middleware.GetPenggunaContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	cfg, err := h.service.Current(c.Request.Context(), session.PenggunaID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: "config_not_assigned"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	risk, err := h.risk.Get(c.Request.Context(), session.PenggunaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	etag := service.ConfigETag(cfg)
+	if risk.Halted {
+		etag = strings.TrimSuffix(etag, `"`) + `-halted"`
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version":    cfg.Version,
+		"config":     cfg.Config,
+		"updated_at": cfg.CreatedAt,
+		"halted":     risk.Halted,
+		"halt":       newHaltInfo(risk),
+	})
+}
+
+func newHaltInfo(risk model.PenggunaRisk) *haltInfo {
+	if !risk.Halted {
+		return nil
+	}
+	return &haltInfo{Rule: risk.HaltRule, Reason: risk.HaltReason, HaltedAt: risk.HaltedAt}
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
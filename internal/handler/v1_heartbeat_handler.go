@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/presence"
+	"koalbot_api/internal/service"
+)
+
+type V1HeartbeatHandler struct {
+	registry *presence.Registry
+	risk     *service.RiskService
+}
+
+func NewV1HeartbeatHandler(registry *presence.Registry, risk *service.RiskService) *V1HeartbeatHandler {
+	return &V1HeartbeatHandler{registry: registry, risk: risk}
+}
+
+type v1HeartbeatRequest struct {
+	ClientVersion string `json:"client_version"`
+	Running       *bool  `json:"running"`
+	State         string `json:"state"`
+}
+
+func (h *V1HeartbeatHandler) Heartbeat(c *gin.Context) {
+	session, ok := middleware.GetPenggunaContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req v1HeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	req.ClientVersion = strings.TrimSpace(req.ClientVersion)
+	if req.ClientVersion == "" || req.Running == nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "client_version_running_required"})
+		return
+	}
+
+	now := time.Now().UTC()
+	h.registry.Touch(model.PenggunaPresence{
+		PenggunaID:    session.PenggunaID,
+		IDPengguna:    session.IDPengguna,
+		DeviceID:      session.DeviceID,
+		DeviceType:    strings.TrimSpace(c.GetHeader("Device-Type")),
+		ClientVersion: req.ClientVersion,
+		Running:       *req.Running,
+		State:         strings.TrimSpace(req.State),
+		IP:            c.ClientIP(),
+		LastSeen:      now,
+	})
+
+	risk, err := h.risk.Get(c.Request.Context(), session.PenggunaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "ok",
+		"server_time":  now,
+		"interval_sec": int((h.registry.TTL() / 3).Seconds()),
+		"halted":       risk.Halted,
+		"halt":         newHaltInfo(risk),
+	})
+}
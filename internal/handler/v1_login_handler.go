@@ -11,8 +11,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 
+	"koalbot_api/internal/logging"
 	"koalbot_api/internal/model"
 	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
 	"koalbot_api/internal/stockity"
 )
 
@@ -22,17 +24,23 @@ type V1LoginHandler struct {
 	stockity *stockity.Client
 	master   *repository.MasterPenggunaRepository
 	detail   *repository.PenggunaDetailRepository
+	audit    *service.LoginAuditService
+	devices  *service.DeviceService
+	versions *service.ClientVersionService
+	risk     *service.RiskService
 	secret   []byte
-	apiURL   string
 }
 
-func NewV1LoginHandler(stockityClient *stockity.Client, master *repository.MasterPenggunaRepository, detail *repository.PenggunaDetailRepository, secret string, apiURL string) *V1LoginHandler {
+func NewV1LoginHandler(stockityClient *stockity.Client, master *repository.MasterPenggunaRepository, detail *repository.PenggunaDetailRepository, audit *service.LoginAuditService, devices *service.DeviceService, versions *service.ClientVersionService, risk *service.RiskService, secret string) *V1LoginHandler {
 	return &V1LoginHandler{
 		stockity: stockityClient,
 		master:   master,
 		detail:   detail,
+		audit:    audit,
+		devices:  devices,
+		versions: versions,
+		risk:     risk,
 		secret:   []byte(secret),
-		apiURL:   apiURL,
 	}
 }
 
@@ -46,6 +54,15 @@ type v1LoginResponse struct {
 	TokenAPI    string          `json:"token_api"`
 	UserProfile penggunaProfile `json:"user_profile"`
 	APIURL      string          `json:"api_url"`
+	Update      *clientUpdate   `json:"update,omitempty"`
+}
+
+type clientUpdate struct {
+	Required           bool   `json:"required"`
+	CurrentVersion     string `json:"current_version"`
+	MinVersion         string `json:"min_version,omitempty"`
+	RecommendedVersion string `json:"recommended_version,omitempty"`
+	DownloadURL        string `json:"download_url,omitempty"`
 }
 
 type penggunaProfile struct {
@@ -107,12 +124,37 @@ func (h *V1LoginHandler) Login(c *gin.Context) {
 		return
 	}
 
+	attempt := model.PenggunaLogin{
+		Email:      strings.ToLower(strings.TrimSpace(req.Email)),
+		DeviceID:   deviceID,
+		DeviceType: deviceType,
+		IP:         c.ClientIP(),
+	}
+
+	clientVersion := strings.TrimSpace(c.GetHeader("Client-Version"))
+	versionCheck, err := h.versions.Check(c.Request.Context(), deviceType, clientVersion)
+	if err != nil {
+		h.record(c, attempt, model.LoginError)
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+	if versionCheck.Status == service.ClientVersionOutdated {
+		h.record(c, attempt, model.LoginClientOutdated)
+		c.JSON(http.StatusUpgradeRequired, gin.H{
+			"error":  "client_outdated",
+			"update": newClientUpdate(versionCheck, clientVersion),
+		})
+		return
+	}
+
 	signIn, err := h.stockity.SignIn(c.Request.Context(), deviceID, deviceType, req.Email, req.Password)
 	if err != nil {
 		if errors.Is(err, stockity.ErrInvalidCredentials) {
+			h.record(c, attempt, model.LoginInvalidCredentials)
 			c.JSON(http.StatusUnauthorized, errorResponse{Error: "invalid_credentials"})
 			return
 		}
+		h.record(c, attempt, model.LoginUpstreamError)
 		var upstreamErr *stockity.UpstreamError
 		if errors.As(err, &upstreamErr) {
 			if upstreamErr.Status == http.StatusUnprocessableEntity {
@@ -128,79 +170,152 @@ func (h *V1LoginHandler) Login(c *gin.Context) {
 
 	userID, err := stockity.ParseUserID(signIn.UserID)
 	if err != nil {
+		h.record(c, attempt, model.LoginUpstreamError)
 		c.JSON(http.StatusBadGateway, gin.H{"error": "upstream_error", "message": "invalid user_id from upstream"})
 		return
 	}
 
+	attempt.IDPengguna = &userID
+
 	master, err := h.master.GetByIDPengguna(c.Request.Context(), userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			_, createErr := h.master.Create(c.Request.Context(), userID, nil, "stockity", false)
+			deleted, deletedErr := h.master.GetDeletedByIDPengguna(c.Request.Context(), userID)
+			if deletedErr == nil {
+				attempt.PenggunaID = &deleted.ID
+				h.record(c, attempt, model.LoginDeleted)
+				c.JSON(http.StatusForbidden, errorResponse{Error: "account_deleted"})
+				return
+			}
+			if !errors.Is(deletedErr, sql.ErrNoRows) {
+				h.record(c, attempt, model.LoginError)
+				c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+				return
+			}
+			created, createErr := h.master.Create(c.Request.Context(), userID, nil, "stockity", false)
 			if createErr != nil {
+				h.record(c, attempt, model.LoginError)
 				c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 				return
 			}
+			attempt.PenggunaID = &created.ID
+			h.record(c, attempt, model.LoginInactive)
 			c.JSON(http.StatusForbidden, errorResponse{Error: "account_inactive"})
 			return
 		}
+		h.record(c, attempt, model.LoginError)
 		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 		return
 	}
+	attempt.PenggunaID = &master.ID
 
 	if !master.Active {
+		h.record(c, attempt, model.LoginInactive)
 		c.JSON(http.StatusForbidden, errorResponse{Error: "account_inactive"})
 		return
 	}
 
 	detail, err := h.detail.GetByPenggunaID(c.Request.Context(), master.ID)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
-			return
-		}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		h.record(c, attempt, model.LoginError)
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+	cached := err == nil
 
-		profile, err := h.stockity.GetProfile(c.Request.Context(), deviceID, deviceType, signIn.AuthToken)
-		if err != nil {
-			var upstreamErr *stockity.UpstreamError
-			if errors.As(err, &upstreamErr) {
-				c.JSON(http.StatusBadGateway, gin.H{"error": "profile_fetch_failed", "message": upstreamErr.Body})
-				return
-			}
-			c.JSON(http.StatusBadGateway, errorResponse{Error: "profile_fetch_failed"})
+	profile, err := h.stockity.GetProfile(c.Request.Context(), deviceID, deviceType, signIn.AuthToken)
+	switch {
+	case err != nil && cached:
+		logging.Warnf("profile sync for pengguna %d: %v", master.ID, err)
+	case err != nil:
+		h.record(c, attempt, model.LoginUpstreamError)
+		var upstreamErr *stockity.UpstreamError
+		if errors.As(err, &upstreamErr) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "profile_fetch_failed", "message": upstreamErr.Body})
 			return
 		}
-
+		c.JSON(http.StatusBadGateway, errorResponse{Error: "profile_fetch_failed"})
+		return
+	default:
 		mapped, err := mapProfileToDetail(profile, master.ID)
 		if err != nil {
+			h.record(c, attempt, model.LoginError)
 			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 			return
 		}
 
 		if err := h.detail.Upsert(c.Request.Context(), mapped); err != nil {
+			h.record(c, attempt, model.LoginError)
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+			return
+		}
+		if _, err := h.risk.Evaluate(c.Request.Context(), master.ID, nil); err != nil {
+			h.record(c, attempt, model.LoginError)
 			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 			return
 		}
 		detail = mapped
 	}
 
-	token, err := h.issueBEToken(master, time.Now().UTC())
+	err = h.devices.Bind(c.Request.Context(), model.PenggunaDevice{
+		PenggunaID: master.ID,
+		DeviceID:   deviceID,
+		DeviceType: deviceType,
+		LastIP:     attempt.IP,
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrDeviceLimit) {
+			h.record(c, attempt, model.LoginDeviceLimit)
+			c.JSON(http.StatusForbidden, gin.H{"error": "device_limit_reached", "max_devices": h.devices.Limit()})
+			return
+		}
+		h.record(c, attempt, model.LoginError)
 		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, v1LoginResponse{
+	token, err := h.issueBEToken(master, deviceID, time.Now().UTC())
+	if err != nil {
+		h.record(c, attempt, model.LoginError)
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+	h.record(c, attempt, model.LoginSuccess)
+
+	response := v1LoginResponse{
 		Token:       token,
 		TokenAPI:    signIn.AuthToken,
 		UserProfile: detailToProfile(detail),
-		APIURL:      h.apiURL,
-	})
+		APIURL:      h.stockity.BaseURL(),
+	}
+	if versionCheck.Status == service.ClientVersionRecommended {
+		response.Update = newClientUpdate(versionCheck, clientVersion)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func newClientUpdate(check service.ClientVersionCheck, current string) *clientUpdate {
+	return &clientUpdate{
+		Required:           check.Status == service.ClientVersionOutdated,
+		CurrentVersion:     current,
+		MinVersion:         check.Policy.MinVersion,
+		RecommendedVersion: check.Policy.RecommendedVersion,
+		DownloadURL:        check.Policy.DownloadURL,
+	}
+}
+
+func (h *V1LoginHandler) record(c *gin.Context, attempt model.PenggunaLogin, outcome string) {
+	attempt.Outcome = outcome
+	if err := h.audit.Record(c.Request.Context(), attempt); err != nil {
+		logging.Errorf("login audit: %v", err)
+	}
 }
 
-func (h *V1LoginHandler) issueBEToken(master model.MasterPengguna, lastSeen time.Time) (string, error) {
+func (h *V1LoginHandler) issueBEToken(master model.MasterPengguna, deviceID string, lastSeen time.Time) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":       master.UUID,
 		"user_id":   master.IDPengguna,
+		"device_id": deviceID,
 		"last_seen": lastSeen.Unix(),
 		"exp":       time.Now().Add(beTokenTTL).Unix(),
 		"iat":       time.Now().Unix(),
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/service"
+)
+
+type V1TradeHandler struct {
+	service *service.TradeService
+}
+
+func NewV1TradeHandler(service *service.TradeService) *V1TradeHandler {
+	return &V1TradeHandler{service: service}
+}
+
+type reportTradesRequest struct {
+	Trades []reportTradeItem `json:"trades"`
+}
+
+type reportTradeItem struct {
+	ClientTradeID string     `json:"client_trade_id"`
+	Asset         string     `json:"asset"`
+	Direction     string     `json:"direction"`
+	Amount        float64    `json:"amount"`
+	AccountType   string     `json:"account_type"`
+	OpenedAt      time.Time  `json:"open_time"`
+	ClosedAt      *time.Time `json:"close_time"`
+	Result        string     `json:"result"`
+	Payout        *float64   `json:"payout"`
+}
+
+type tradeResultItem struct {
+	ClientTradeID string `json:"client_trade_id"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+func (h *V1TradeHandler) Report(c *gin.Context) {
+	session, ok := middleware.GetPenggunaContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req reportTradesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+
+	trades := make([]model.PenggunaTrade, 0, len(req.Trades))
+	for _, item := range req.Trades {
+		trades = append(trades, model.PenggunaTrade{
+			ClientTradeID: item.ClientTradeID,
+			Asset:         item.Asset,
+			Direction:     item.Direction,
+			Amount:        item.Amount,
+			AccountType:   item.AccountType,
+			OpenedAt:      item.OpenedAt,
+			ClosedAt:      item.ClosedAt,
+			Result:        item.Result,
+			Payout:        item.Payout,
+		})
+	}
+
+	results, halted, err := h.service.Ingest(c.Request.Context(), session.PenggunaID, session.DeviceID, trades)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTradeBatchEmpty), errors.Is(err, service.ErrTradeBatchTooLarge):
+			c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		}
+		return
+	}
+
+	accepted := 0
+	items := make([]tradeResultItem, 0, len(results))
+	for _, result := range results {
+		if result.Status != model.TradeRejected {
+			accepted++
+		}
+		items = append(items, tradeResultItem{
+			ClientTradeID: result.ClientTradeID,
+			Status:        result.Status,
+			Error:         result.Error,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accepted": accepted, "results": items, "halted": halted})
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/middleware"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+	"koalbot_api/internal/service"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+type webhookRequest struct {
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Events       []string `json:"events"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+type webhookItem struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	URL       string     `json:"url"`
+	Events    []string   `json:"events"`
+	Active    bool       `json:"active"`
+	Secret    string     `json:"secret,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy string     `json:"created_by"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	UpdatedBy string     `json:"updated_by"`
+}
+
+type webhookDeliveryItem struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	LastResponse   string          `json:"last_response,omitempty"`
+	LastDurationMS *int            `json:"last_duration_ms"`
+	RedeliveryOf   *int64          `json:"redelivery_of"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+func (h *WebhookHandler) Events(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": model.WebhookEvents})
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	webhooks, err := h.service.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]webhookItem, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		items = append(items, newWebhookItem(webhook, false))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+func (h *WebhookHandler) Get(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookItem(webhook, false))
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	webhook, err := h.service.Create(c.Request.Context(), model.Webhook{
+		Name:      req.Name,
+		URL:       req.URL,
+		Events:    req.Events,
+		Active:    active,
+		CreatedBy: authCtx.UID,
+	})
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newWebhookItem(webhook, true))
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_json"})
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	webhook, err := h.service.Update(c.Request.Context(), model.Webhook{
+		ID:        id,
+		Name:      req.Name,
+		URL:       req.URL,
+		Events:    req.Events,
+		Active:    active,
+		UpdatedBy: authCtx.UID,
+	}, req.RotateSecret)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookItem(webhook, req.RotateSecret))
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func (h *WebhookHandler) Test(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return
+	}
+
+	delivery, err := h.service.Test(c.Request.Context(), id, authCtx.UID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, newWebhookDeliveryItem(delivery))
+}
+
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	q, ok := parseQuery(c, repository.WebhookDeliveryQuery)
+	if !ok {
+		return
+	}
+
+	deliveries, page, err := h.service.Deliveries(c.Request.Context(), id, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+		return
+	}
+
+	items := make([]webhookDeliveryItem, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, newWebhookDeliveryItem(delivery))
+	}
+	c.JSON(http.StatusOK, queryspec.NewResponse(items, page, q))
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil || deliveryID <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_delivery_id"})
+		return
+	}
+
+	delivery, err := h.service.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, newWebhookDeliveryItem(delivery))
+}
+
+func parseWebhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("webhook_id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid_webhook_id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNameRequired),
+		errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrInvalidWebhookEvent):
+		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, errorResponse{Error: "not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "server_error"})
+	}
+}
+
+func newWebhookItem(webhook model.Webhook, includeSecret bool) webhookItem {
+	item := webhookItem{
+		ID:        webhook.ID,
+		Name:      webhook.Name,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+		CreatedBy: webhook.CreatedBy,
+		UpdatedAt: webhook.UpdatedAt,
+		UpdatedBy: webhook.UpdatedBy,
+	}
+	if includeSecret {
+		item.Secret = webhook.Secret
+	}
+	return item
+}
+
+func newWebhookDeliveryItem(delivery model.WebhookDelivery) webhookDeliveryItem {
+	item := webhookDeliveryItem{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		Event:          delivery.Event,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		LastResponse:   delivery.LastResponse,
+		LastDurationMS: delivery.LastDurationMS,
+		RedeliveryOf:   delivery.RedeliveryOf,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	if delivery.Status == model.DeliveryPending {
+		next := delivery.NextAttemptAt
+		item.NextAttemptAt = &next
+	}
+	return item
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+var level = new(slog.LevelVar)
+
+func ParseLevel(raw string) (slog.Level, error) {
+	var parsed slog.Level
+	err := parsed.UnmarshalText([]byte(strings.TrimSpace(raw)))
+	return parsed, err
+}
+
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+func Enabled(l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func Infof(format string, args ...any) {
+	logf(slog.LevelInfo, format, args...)
+}
+
+func Warnf(format string, args ...any) {
+	logf(slog.LevelWarn, format, args...)
+}
+
+func Errorf(format string, args ...any) {
+	logf(slog.LevelError, format, args...)
+}
+
+func logf(l slog.Level, format string, args ...any) {
+	if Enabled(l) {
+		log.Output(3, fmt.Sprintf(format, args...))
+	}
+}
@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+
+	"koalbot_api/internal/service"
 )
 
 const authContextKey = "auth_context"
@@ -21,10 +25,15 @@ type Claims struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
 	LastSeen int64  `json:"last_seen"`
+	Version  int    `json:"tv"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware(secret string) gin.HandlerFunc {
+type TokenVerifier interface {
+	VerifyUser(ctx context.Context, uid string, version int) (string, error)
+}
+
+func AuthMiddleware(secret string, verifier TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -51,10 +60,21 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		role, err := verifier.VerifyUser(c.Request.Context(), claims.Subject, claims.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrTokenRevoked), errors.Is(err, service.ErrUserDisabled):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			}
+			return
+		}
+
 		ctx := AuthContext{
 			UID:      claims.Subject,
 			Username: claims.Username,
-			Role:     claims.Role,
+			Role:     role,
 			LastSeen: claims.LastSeen,
 		}
 		c.Set(authContextKey, ctx)
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+type CORS struct {
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+func NewCORS(cfg cors.Config) *CORS {
+	m := &CORS{}
+	m.Update(cfg)
+	return m
+}
+
+func (m *CORS) Update(cfg cors.Config) {
+	handler := cors.New(cfg)
+	m.handler.Store(&handler)
+}
+
+func (m *CORS) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		(*m.handler.Load())(c)
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/service"
+)
+
+const penggunaContextKey = "pengguna_context"
+
+type PenggunaContext struct {
+	PenggunaID int64
+	UUID       string
+	IDPengguna int64
+	DeviceID   string
+}
+
+type PenggunaClaims struct {
+	UserID   int64  `json:"user_id"`
+	DeviceID string `json:"device_id"`
+	LastSeen int64  `json:"last_seen"`
+	jwt.RegisteredClaims
+}
+
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, uuid, deviceID string) (model.MasterPengguna, error)
+}
+
+func PenggunaAuthMiddleware(secret string, verifier SessionVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing_authorization"})
+			return
+		}
+
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_authorization"})
+			return
+		}
+
+		claims := &PenggunaClaims{}
+		token, err := jwt.ParseWithClaims(strings.TrimSpace(parts[1]), claims, func(token *jwt.Token) (any, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return []byte(secret), nil
+		})
+		if err != nil || !token.Valid || claims.Subject == "" || claims.DeviceID == "" || claims.UserID == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			return
+		}
+
+		master, err := verifier.VerifySession(c.Request.Context(), claims.Subject, claims.DeviceID)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrSessionNotFound), errors.Is(err, service.ErrSessionDeviceUnbound):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrSessionInactive):
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			}
+			return
+		}
+
+		c.Set(penggunaContextKey, PenggunaContext{
+			PenggunaID: master.ID,
+			UUID:       master.UUID,
+			IDPengguna: master.IDPengguna,
+			DeviceID:   claims.DeviceID,
+		})
+		c.Next()
+	}
+}
+
+func GetPenggunaContext(c *gin.Context) (PenggunaContext, bool) {
+	val, ok := c.Get(penggunaContextKey)
+	if !ok {
+		return PenggunaContext{}, false
+	}
+	ctx, ok := val.(PenggunaContext)
+	return ctx, ok
+}
@@ -41,6 +41,18 @@ func (l *ipLimiter) getLimiter(ip string) *rate.Limiter {
 	return limiter
 }
 
+func (l *ipLimiter) setLimit(r rate.Limit, b int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.r = r
+	l.b = b
+	for _, limiter := range l.limiters {
+		limiter.SetLimit(r)
+		limiter.SetBurst(b)
+	}
+}
+
 func (l *ipLimiter) cleanup() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -54,7 +66,11 @@ func (l *ipLimiter) cleanup() {
 	}
 }
 
-func RateLimit(perSecond float64, burst int, cleanupWindow time.Duration) gin.HandlerFunc {
+type RateLimiter struct {
+	limiter *ipLimiter
+}
+
+func NewRateLimiter(perSecond float64, burst int, cleanupWindow time.Duration) *RateLimiter {
 	limiter := newIPLimiter(rate.Limit(perSecond), burst, cleanupWindow)
 
 	go func() {
@@ -65,9 +81,17 @@ func RateLimit(perSecond float64, burst int, cleanupWindow time.Duration) gin.Ha
 		}
 	}()
 
+	return &RateLimiter{limiter: limiter}
+}
+
+func (l *RateLimiter) SetLimit(perSecond float64, burst int) {
+	l.limiter.setLimit(rate.Limit(perSecond), burst)
+}
+
+func (l *RateLimiter) Handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
-		if !limiter.getLimiter(ip).Allow() {
+		if !l.limiter.getLimiter(ip).Allow() {
 			c.AbortWithStatusJSON(429, gin.H{"error": "rate_limited"})
 			return
 		}
@@ -5,9 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 )
 
-func Run(db *sql.DB, path string) error {
+type Result struct {
+	Path      string
+	AppliedAt time.Time
+}
+
+func Run(db *sql.DB, path string) (Result, error) {
 	paths := candidatePaths(path)
 	var lastErr error
 
@@ -21,19 +27,21 @@ func Run(db *sql.DB, path string) error {
 				lastErr = err
 				continue
 			}
-			return err
+			return Result{}, err
 		}
 		if len(sqlBytes) == 0 {
-			return nil
+			return Result{Path: p, AppliedAt: time.Now().UTC()}, nil
+		}
+		if _, err := db.Exec(string(sqlBytes)); err != nil {
+			return Result{}, err
 		}
-		_, err = db.Exec(string(sqlBytes))
-		return err
+		return Result{Path: p, AppliedAt: time.Now().UTC()}, nil
 	}
 
-	if lastErr != nil {
-		return fmt.Errorf("migration file not found: %w", lastErr)
+	if lastErr == nil {
+		lastErr = os.ErrNotExist
 	}
-	return nil
+	return Result{}, fmt.Errorf("migration file not found: %w", lastErr)
 }
 
 func candidatePaths(path string) []string {
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type BalancePoint struct {
+	Bucket  time.Time
+	Open    float64
+	High    float64
+	Low     float64
+	Close   float64
+	Samples int
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ConfigSourceManual   = "manual"
+	ConfigSourceTemplate = "template"
+	ConfigSourceRollback = "rollback"
+)
+
+type BotConfigTemplate struct {
+	ID          int64
+	Name        string
+	Description string
+	Config      json.RawMessage
+	CreatedAt   time.Time
+	CreatedBy   string
+	UpdatedAt   *time.Time
+	UpdatedBy   string
+}
+
+type PenggunaConfig struct {
+	ID         int64
+	PenggunaID int64
+	Version    int
+	Config     json.RawMessage
+	Source     string
+	TemplateID *int64
+	RollbackOf *int
+	Note       string
+	CreatedBy  string
+	CreatedAt  time.Time
+}
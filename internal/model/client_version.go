@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type ClientVersionPolicy struct {
+	DeviceType         string
+	MinVersion         string
+	RecommendedVersion string
+	DownloadURL        string
+	UpdatedAt          time.Time
+	UpdatedBy          string
+}
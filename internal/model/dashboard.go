@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type DashboardSummary struct {
+	Total        int
+	Active       int
+	Inactive     int
+	TotalBalance float64
+	AvgBalance   float64
+	TotalDeposit float64
+	AvgDeposit   float64
+}
+
+type DashboardBreakdown struct {
+	Jenis   []DashboardGroup
+	Country []DashboardGroup
+}
+
+type DashboardGroup struct {
+	Key    string
+	Total  int
+	Active int
+}
+
+type DashboardPoint struct {
+	Bucket        time.Time
+	Registrations int
+	Activations   int
+	Logins        int
+}
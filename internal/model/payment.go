@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	PaymentApplied   = "applied"
+	PaymentDuplicate = "duplicate"
+	PaymentIgnored   = "ignored"
+)
+
+type Payment struct {
+	ID                int64
+	Provider          string
+	ProviderPaymentID string
+	Reference         string
+	PenggunaID        *int64
+	IDPengguna        int64
+	Amount            float64
+	Currency          string
+	PeriodDays        int
+	ActiveUntil       *time.Time
+	PaidAt            time.Time
+	Payload           json.RawMessage
+	ReceivedAt        time.Time
+}
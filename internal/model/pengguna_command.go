@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	CommandStop         = "stop"
+	CommandPause        = "pause"
+	CommandResume       = "resume"
+	CommandReloadConfig = "reload_config"
+)
+
+const (
+	CommandPending   = "pending"
+	CommandDelivered = "delivered"
+	CommandAcked     = "acked"
+	CommandFailed    = "failed"
+	CommandExpired   = "expired"
+	CommandCancelled = "cancelled"
+)
+
+type PenggunaCommand struct {
+	ID          int64
+	PenggunaID  int64
+	Command     string
+	Payload     json.RawMessage
+	Status      string
+	DeviceID    string
+	Attempts    int
+	Result      string
+	CreatedBy   string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	DeliveredAt *time.Time
+	AckedAt     *time.Time
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	LoginSuccess            = "success"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginInactive           = "inactive"
+	LoginDeleted            = "deleted"
+	LoginDeviceLimit        = "device_limit"
+	LoginClientOutdated     = "client_outdated"
+	LoginUpstreamError      = "upstream_error"
+	LoginError              = "error"
+)
+
+type PenggunaLogin struct {
+	ID         int64
+	PenggunaID *int64
+	IDPengguna *int64
+	Email      string
+	Outcome    string
+	DeviceID   string
+	DeviceType string
+	IP         string
+	CreatedAt  time.Time
+}
+
+type PenggunaAlert struct {
+	ID         int64
+	PenggunaID int64
+	IDPengguna int64
+	Kind       string
+	Detail     json.RawMessage
+	CreatedAt  time.Time
+}
+
+type PenggunaDevice struct {
+	ID         int64
+	PenggunaID int64
+	DeviceID   string
+	DeviceType string
+	LastIP     string
+	BoundAt    time.Time
+	LastSeenAt time.Time
+}
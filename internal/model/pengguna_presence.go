@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type PenggunaPresence struct {
+	PenggunaID    int64
+	IDPengguna    int64
+	DeviceID      string
+	DeviceType    string
+	ClientVersion string
+	Running       bool
+	State         string
+	IP            string
+	LastSeen      time.Time
+}
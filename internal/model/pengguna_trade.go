@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+const (
+	TradeCall = "call"
+	TradePut  = "put"
+)
+
+const (
+	TradeWin  = "win"
+	TradeLoss = "loss"
+	TradeDraw = "draw"
+)
+
+const (
+	TradeCreated   = "created"
+	TradeUpdated   = "updated"
+	TradeDuplicate = "duplicate"
+	TradeRejected  = "rejected"
+)
+
+type PenggunaTrade struct {
+	ID            int64
+	PenggunaID    int64
+	IDPengguna    int64
+	ClientTradeID string
+	DeviceID      string
+	Asset         string
+	Direction     string
+	Amount        float64
+	AccountType   string
+	OpenedAt      time.Time
+	ClosedAt      *time.Time
+	Result        string
+	Payout        *float64
+	CreatedAt     time.Time
+	UpdatedAt     *time.Time
+}
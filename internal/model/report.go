@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+type TradeOutcome struct {
+	PenggunaID int64
+	IDPengguna int64
+	ClosedAt   time.Time
+	Amount     float64
+	Payout     float64
+	Result     string
+}
+
+type PerformanceStats struct {
+	Trades        int
+	Wins          int
+	Losses        int
+	Draws         int
+	WinRate       float64
+	Volume        float64
+	Profit        float64
+	MaxDrawdown   float64
+	MaxWinStreak  int
+	MaxLossStreak int
+	CurrentStreak int
+}
+
+type PerformancePeriod struct {
+	Bucket  time.Time
+	Trades  int
+	Wins    int
+	Losses  int
+	Draws   int
+	WinRate float64
+	Volume  float64
+	Profit  float64
+}
+
+type BalanceChange struct {
+	Start    float64
+	End      float64
+	Accounts int
+}
+
+type PerformanceReport struct {
+	PenggunaID  int64
+	AccountType string
+	From        time.Time
+	To          time.Time
+	Period      string
+	Summary     PerformanceStats
+	Periods     []PerformancePeriod
+	Balance     *BalanceChange
+}
+
+type TraderPerformance struct {
+	PenggunaID int64
+	IDPengguna int64
+	PerformanceStats
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+const (
+	RiskRuleDailyLoss     = "max_daily_loss"
+	RiskRuleTradesPerHour = "max_trades_per_hour"
+	RiskRuleStakePct      = "max_stake_pct"
+	RiskRuleTradingHours  = "trading_hours"
+	RiskRuleManual        = "manual"
+)
+
+type RiskRules struct {
+	MaxDailyLoss     *float64
+	MaxTradesPerHour *int
+	MaxStakePct      *float64
+	TradingStart     *string
+	TradingEnd       *string
+	Timezone         *string
+}
+
+type RiskPlan struct {
+	ID        int64
+	Name      string
+	Rules     RiskRules
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt *time.Time
+	UpdatedBy string
+}
+
+type PenggunaRisk struct {
+	PenggunaID int64
+	PlanID     *int64
+	PlanName   string
+	Plan       RiskRules
+	Overrides  RiskRules
+	Halted     bool
+	HaltedAt   *time.Time
+	HaltRule   string
+	HaltReason string
+	UpdatedAt  *time.Time
+	UpdatedBy  string
+}
+
+type RiskExposure struct {
+	DailyProfit float64
+	HourTrades  int
+	Balance     *float64
+}
@@ -14,19 +14,37 @@ type User struct {
 	UpdatedAt *time.Time
 	UpdatedBy *string
 	DeletedAt *time.Time
+	DeletedBy *string
 	LastSeen  *time.Time
+
+	TokenVersion int
 }
 
 type MasterPengguna struct {
-	ID         int64
-	UUID       string
-	IDPengguna int64
-	Telegram   *string
-	Jenis      string
-	Active     bool
-	CreatedAt  time.Time
-	UpdatedAt  *time.Time
-	DeletedAt  *time.Time
+	ID          int64
+	UUID        string
+	IDPengguna  int64
+	Telegram    *string
+	Jenis       string
+	Active      bool
+	CreatedAt   time.Time
+	UpdatedAt   *time.Time
+	DeletedAt   *time.Time
+	LastLoginAt *time.Time
+	ActiveUntil *time.Time
+	Detail      *PenggunaDetailSummary
+}
+
+type PenggunaDetailSummary struct {
+	ID        int64
+	FirstName string
+	LastName  string
+	Nickname  string
+	Email     string
+	Balance   float64
+	Currency  string
+	Country   string
+	Blocked   bool
 }
 
 type PenggunaDetail struct {
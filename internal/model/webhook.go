@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookPenggunaRegistered  = "pengguna.registered"
+	WebhookPenggunaActivated   = "pengguna.activated"
+	WebhookPenggunaDeactivated = "pengguna.deactivated"
+	WebhookPenggunaExpired     = "pengguna.expired"
+	WebhookPenggunaHalted      = "pengguna.halted"
+	WebhookPenggunaResumed     = "pengguna.resumed"
+	WebhookTest                = "webhook.test"
+)
+
+var WebhookEvents = []string{
+	WebhookPenggunaRegistered,
+	WebhookPenggunaActivated,
+	WebhookPenggunaDeactivated,
+	WebhookPenggunaExpired,
+	WebhookPenggunaHalted,
+	WebhookPenggunaResumed,
+}
+
+const (
+	DeliveryPending    = "pending"
+	DeliveryDelivering = "delivering"
+	DeliverySucceeded  = "succeeded"
+	DeliveryFailed     = "failed"
+)
+
+type Webhook struct {
+	ID        int64
+	Name      string
+	URL       string
+	Secret    string
+	Events    []string
+	Active    bool
+	CreatedAt time.Time
+	CreatedBy string
+	UpdatedAt *time.Time
+	UpdatedBy string
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	EventID        *int64
+	Event          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      string
+	LastResponse   string
+	LastDurationMS *int
+	RedeliveryOf   *int64
+	CreatedAt      time.Time
+	UpdatedAt      *time.Time
+	DeliveredAt    *time.Time
+}
@@ -0,0 +1,122 @@
+package payment
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const midtransTimeLayout = "2006-01-02 15:04:05"
+
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+type midtransNotification struct {
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	Currency          string `json:"currency"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status,omitempty"`
+	TransactionTime   string `json:"transaction_time"`
+	SettlementTime    string `json:"settlement_time,omitempty"`
+	SignatureKey      string `json:"signature_key"`
+}
+
+type Midtrans struct {
+	serverKey string
+}
+
+func NewMidtrans(serverKey string) *Midtrans {
+	return &Midtrans{serverKey: serverKey}
+}
+
+func (m *Midtrans) Name() string {
+	return "midtrans"
+}
+
+func (m *Midtrans) Parse(_ http.Header, body []byte) (Notification, error) {
+	var n midtransNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return Notification{}, ErrInvalidPayload
+	}
+	if n.TransactionID == "" || n.OrderID == "" || n.StatusCode == "" || n.GrossAmount == "" {
+		return Notification{}, ErrInvalidPayload
+	}
+
+	expected := m.signature(n.OrderID, n.StatusCode, n.GrossAmount)
+	if subtle.ConstantTimeCompare([]byte(strings.ToLower(n.SignatureKey)), []byte(expected)) != 1 {
+		return Notification{}, ErrInvalidSignature
+	}
+
+	amount, err := strconv.ParseFloat(n.GrossAmount, 64)
+	if err != nil || amount < 0 {
+		return Notification{}, ErrInvalidPayload
+	}
+	idPengguna, ok := midtransIDPengguna(n.OrderID)
+	if !ok {
+		return Notification{}, ErrInvalidPayload
+	}
+
+	currency := n.Currency
+	if currency == "" {
+		currency = "IDR"
+	}
+	paidAt := n.SettlementTime
+	if paidAt == "" {
+		paidAt = n.TransactionTime
+	}
+	at, err := time.ParseInLocation(midtransTimeLayout, paidAt, jakarta)
+	if err != nil {
+		at = time.Now()
+	}
+
+	return Notification{
+		PaymentID:  n.TransactionID,
+		Reference:  n.OrderID,
+		IDPengguna: idPengguna,
+		Amount:     amount,
+		Currency:   currency,
+		Paid:       n.TransactionStatus == "settlement" || (n.TransactionStatus == "capture" && n.FraudStatus == "accept"),
+		PaidAt:     at.UTC(),
+	}, nil
+}
+
+func (m *Midtrans) Sample(transactionID, orderID string, amount float64, paidAt time.Time) ([]byte, error) {
+	n := midtransNotification{
+		TransactionID:     transactionID,
+		OrderID:           orderID,
+		StatusCode:        "200",
+		GrossAmount:       strconv.FormatFloat(amount, 'f', 2, 64),
+		Currency:          "IDR",
+		TransactionStatus: "settlement",
+		TransactionTime:   paidAt.In(jakarta).Format(midtransTimeLayout),
+		SettlementTime:    paidAt.In(jakarta).Format(midtransTimeLayout),
+	}
+	n.SignatureKey = m.signature(n.OrderID, n.StatusCode, n.GrossAmount)
+	return json.Marshal(n)
+}
+
+func (m *Midtrans) signature(orderID, statusCode, grossAmount string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + m.serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func MidtransOrderID(idPengguna int64, suffix string) string {
+	return fmt.Sprintf("%d-%s", idPengguna, suffix)
+}
+
+func midtransIDPengguna(orderID string) (int64, bool) {
+	head, _, _ := strings.Cut(orderID, "-")
+	id, err := strconv.ParseInt(head, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,130 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testServerKey = "SB-Mid-server-test"
+
+func TestMidtransParse(t *testing.T) {
+	m := NewMidtrans(testServerKey)
+	paidAt := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	body, err := m.Sample("txn-1", MidtransOrderID(12345, "a1"), 150000, paidAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(map[string]any)
+		wantErr error
+		paid    bool
+	}{
+		{name: "settlement", paid: true},
+		{name: "pending", mutate: func(n map[string]any) { n["transaction_status"] = "pending" }},
+		{name: "capture accepted", mutate: func(n map[string]any) {
+			n["transaction_status"] = "capture"
+			n["fraud_status"] = "accept"
+		}, paid: true},
+		{name: "capture challenged", mutate: func(n map[string]any) {
+			n["transaction_status"] = "capture"
+			n["fraud_status"] = "challenge"
+		}},
+		{name: "uppercase signature", mutate: func(n map[string]any) {
+			n["signature_key"] = strings.ToUpper(n["signature_key"].(string))
+		}, paid: true},
+		{name: "tampered amount", mutate: func(n map[string]any) { n["gross_amount"] = "1.00" }, wantErr: ErrInvalidSignature},
+		{name: "tampered order", mutate: func(n map[string]any) { n["order_id"] = MidtransOrderID(999, "a1") }, wantErr: ErrInvalidSignature},
+		{name: "missing signature", mutate: func(n map[string]any) { delete(n, "signature_key") }, wantErr: ErrInvalidSignature},
+		{name: "missing transaction id", mutate: func(n map[string]any) { delete(n, "transaction_id") }, wantErr: ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := body
+			if tt.mutate != nil {
+				raw = mutate(t, body, tt.mutate)
+			}
+			n, err := m.Parse(nil, raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if n.Paid != tt.paid {
+				t.Errorf("Paid = %v, want %v", n.Paid, tt.paid)
+			}
+			if n.IDPengguna != 12345 || n.Amount != 150000 || n.Currency != "IDR" {
+				t.Errorf("unexpected notification %+v", n)
+			}
+			if !n.PaidAt.Equal(paidAt) {
+				t.Errorf("PaidAt = %s, want %s", n.PaidAt, paidAt)
+			}
+		})
+	}
+}
+
+func TestMidtransReplayKeepsReference(t *testing.T) {
+	m := NewMidtrans(testServerKey)
+	body, err := m.Sample("txn-1", MidtransOrderID(12345, "a1"), 150000, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := m.Parse(nil, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// transaction_id is not covered by the signature, so a replay can change it
+	// freely. The signed order_id must stay the deduplication reference.
+	replayed, err := m.Parse(nil, mutate(t, body, func(n map[string]any) { n["transaction_id"] = "txn-2" }))
+	if err != nil {
+		t.Fatalf("replayed body rejected: %v", err)
+	}
+	if replayed.PaymentID == original.PaymentID {
+		t.Fatalf("PaymentID unchanged: %s", replayed.PaymentID)
+	}
+	if replayed.Reference != original.Reference {
+		t.Errorf("Reference = %q, want %q", replayed.Reference, original.Reference)
+	}
+}
+
+func TestMidtransOrderID(t *testing.T) {
+	tests := []struct {
+		orderID string
+		want    int64
+		ok      bool
+	}{
+		{orderID: MidtransOrderID(42, "abc"), want: 42, ok: true},
+		{orderID: "42", want: 42, ok: true},
+		{orderID: "abc-42"},
+		{orderID: "0-x"},
+		{orderID: "-5-x"},
+		{orderID: ""},
+	}
+	for _, tt := range tests {
+		got, ok := midtransIDPengguna(tt.orderID)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("midtransIDPengguna(%q) = %d, %v; want %d, %v", tt.orderID, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func mutate(t *testing.T, body []byte, fn func(map[string]any)) []byte {
+	t.Helper()
+	var n map[string]any
+	if err := json.Unmarshal(body, &n); err != nil {
+		t.Fatal(err)
+	}
+	fn(n)
+	out, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
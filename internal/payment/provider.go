@@ -0,0 +1,27 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid_signature")
+	ErrInvalidPayload   = errors.New("invalid_payload")
+)
+
+type Notification struct {
+	PaymentID  string
+	Reference  string
+	IDPengguna int64
+	Amount     float64
+	Currency   string
+	Paid       bool
+	PaidAt     time.Time
+}
+
+type Provider interface {
+	Name() string
+	Parse(header http.Header, body []byte) (Notification, error)
+}
@@ -0,0 +1,189 @@
+package presence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"koalbot_api/internal/logging"
+	"koalbot_api/internal/model"
+)
+
+const (
+	EventOnline  = "online"
+	EventUpdate  = "update"
+	EventOffline = "offline"
+)
+
+type Event struct {
+	Type     string
+	Presence model.PenggunaPresence
+}
+
+type Store interface {
+	SavePresence(ctx context.Context, entries []model.PenggunaPresence) error
+}
+
+type Registry struct {
+	store        Store
+	ttl          time.Duration
+	persistEvery time.Duration
+
+	mu      sync.RWMutex
+	entries map[int64]model.PenggunaPresence
+	pending map[int64]model.PenggunaPresence
+
+	subMu sync.Mutex
+	subs  map[chan Event]struct{}
+}
+
+func New(store Store, ttl, persistEvery time.Duration) *Registry {
+	return &Registry{
+		store:        store,
+		ttl:          ttl,
+		persistEvery: persistEvery,
+		entries:      make(map[int64]model.PenggunaPresence),
+		pending:      make(map[int64]model.PenggunaPresence),
+		subs:         make(map[chan Event]struct{}),
+	}
+}
+
+func (r *Registry) TTL() time.Duration {
+	return r.ttl
+}
+
+func (r *Registry) Touch(p model.PenggunaPresence) {
+	r.mu.Lock()
+	prev, ok := r.entries[p.PenggunaID]
+	r.entries[p.PenggunaID] = p
+	r.pending[p.PenggunaID] = p
+	r.mu.Unlock()
+
+	switch {
+	case !ok:
+		r.publish(Event{Type: EventOnline, Presence: p})
+	case prev.DeviceID != p.DeviceID || prev.ClientVersion != p.ClientVersion || prev.Running != p.Running || prev.State != p.State:
+		r.publish(Event{Type: EventUpdate, Presence: p})
+	}
+}
+
+func (r *Registry) Get(penggunaID int64) (model.PenggunaPresence, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.entries[penggunaID]
+	if !ok || time.Since(p.LastSeen) > r.ttl {
+		return model.PenggunaPresence{}, false
+	}
+	return p, true
+}
+
+func (r *Registry) Online() []model.PenggunaPresence {
+	cutoff := time.Now().Add(-r.ttl)
+
+	r.mu.RLock()
+	online := make([]model.PenggunaPresence, 0, len(r.entries))
+	for _, p := range r.entries {
+		if p.LastSeen.After(cutoff) {
+			online = append(online, p)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(online, func(i, j int) bool {
+		return online[i].LastSeen.After(online[j].LastSeen)
+	})
+	return online
+}
+
+func (r *Registry) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+	r.subMu.Lock()
+	r.subs[ch] = struct{}{}
+	r.subMu.Unlock()
+
+	return ch, func() {
+		r.subMu.Lock()
+		delete(r.subs, ch)
+		r.subMu.Unlock()
+	}
+}
+
+func (r *Registry) Run(ctx context.Context) {
+	sweepEvery := r.ttl / 3
+	if sweepEvery < time.Second {
+		sweepEvery = time.Second
+	}
+	sweep := time.NewTicker(sweepEvery)
+	defer sweep.Stop()
+	persist := time.NewTicker(r.persistEvery)
+	defer persist.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			r.persist(flushCtx)
+			cancel()
+			return
+		case <-sweep.C:
+			r.sweep(time.Now())
+		case <-persist.C:
+			r.persist(ctx)
+		}
+	}
+}
+
+func (r *Registry) sweep(now time.Time) {
+	cutoff := now.Add(-r.ttl)
+
+	r.mu.Lock()
+	expired := make([]model.PenggunaPresence, 0)
+	for id, p := range r.entries {
+		if !p.LastSeen.After(cutoff) {
+			expired = append(expired, p)
+			delete(r.entries, id)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, p := range expired {
+		r.publish(Event{Type: EventOffline, Presence: p})
+	}
+}
+
+func (r *Registry) persist(ctx context.Context) {
+	r.mu.Lock()
+	if len(r.pending) == 0 {
+		r.mu.Unlock()
+		return
+	}
+	batch := make([]model.PenggunaPresence, 0, len(r.pending))
+	for _, p := range r.pending {
+		batch = append(batch, p)
+	}
+	r.pending = make(map[int64]model.PenggunaPresence)
+	r.mu.Unlock()
+
+	if err := r.store.SavePresence(ctx, batch); err != nil {
+		logging.Errorf("presence: persist %d entries failed: %v", len(batch), err)
+		r.mu.Lock()
+		for _, p := range batch {
+			if _, ok := r.pending[p.PenggunaID]; !ok {
+				r.pending[p.PenggunaID] = p
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *Registry) publish(event Event) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for ch := range r.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package probe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Result struct {
+	OK        bool
+	Error     string
+	Latency   time.Duration
+	CheckedAt time.Time
+}
+
+type Probe struct {
+	check    func(ctx context.Context) error
+	interval time.Duration
+	timeout  time.Duration
+
+	mu   sync.RWMutex
+	last *Result
+}
+
+func New(check func(ctx context.Context) error, interval, timeout time.Duration) *Probe {
+	return &Probe{
+		check:    check,
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+func (p *Probe) Run(ctx context.Context) {
+	p.runOnce(ctx)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.runOnce(ctx)
+		}
+	}
+}
+
+func (p *Probe) Last() (Result, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.last == nil {
+		return Result{}, false
+	}
+	return *p.last, true
+}
+
+func (p *Probe) runOnce(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := p.check(checkCtx)
+	result := Result{
+		OK:        err == nil,
+		Latency:   time.Since(start),
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	p.mu.Lock()
+	p.last = &result
+	p.mu.Unlock()
+}
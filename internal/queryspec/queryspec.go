@@ -0,0 +1,412 @@
+package queryspec
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"koalbot_api/internal/pagination"
+)
+
+const maxSortFields = 3
+
+var (
+	ErrInvalidSort   = errors.New("invalid_sort")
+	ErrInvalidFilter = errors.New("invalid_filter")
+	ErrInvalidCursor = errors.New("invalid_cursor")
+)
+
+type FilterKind int
+
+const (
+	Bool FilterKind = iota
+	Presence
+	TimeRange
+	NumberRange
+	Equal
+)
+
+type Filter struct {
+	Kind   FilterKind
+	Column string
+}
+
+type Schema struct {
+	Sorts   map[string]string
+	Filters map[string]Filter
+	Default []Sort
+	Key     string
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+type Query struct {
+	pagination.Params
+	Sorts []Sort
+
+	schema     *Schema
+	filters    []string
+	filterArgs []any
+	keyset     bool
+	sorted     bool
+	after      []string
+}
+
+type Page struct {
+	Total      int
+	NextCursor string
+}
+
+type cursorToken struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+func Parse(c *gin.Context, schema *Schema) (Query, error) {
+	params, err := pagination.Parse(c)
+	if err != nil {
+		return Query{}, err
+	}
+
+	q := schema.New(params)
+
+	if raw := c.Query("sort"); raw != "" {
+		sorts, err := schema.parseSort(raw)
+		if err != nil {
+			return Query{}, err
+		}
+		q.Sorts = sorts
+		q.sorted = true
+	}
+
+	for name, filter := range schema.Filters {
+		if err := q.parseFilter(c, name, filter); err != nil {
+			return Query{}, err
+		}
+	}
+
+	if raw, ok := c.GetQuery("cursor"); ok {
+		q.keyset = true
+		q.Offset = 0
+		if raw != "" {
+			if err := q.decodeCursor(raw); err != nil {
+				return Query{}, err
+			}
+		}
+	}
+
+	return q, nil
+}
+
+func (s *Schema) New(params pagination.Params) Query {
+	return Query{
+		Params: params,
+		Sorts:  s.Default,
+		schema: s,
+	}
+}
+
+func (q Query) Keyset() bool {
+	return q.keyset
+}
+
+func (q Query) Sorted() bool {
+	return q.sorted
+}
+
+func (q Query) SortString() string {
+	parts := make([]string, 0, len(q.Sorts))
+	for _, sort := range q.Sorts {
+		if sort.Desc {
+			parts = append(parts, "-"+sort.Field)
+		} else {
+			parts = append(parts, sort.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (q Query) Conditions(argPos int) ([]string, []any) {
+	conditions := make([]string, 0, len(q.filters)+1)
+	args := make([]any, 0, len(q.filterArgs)+len(q.after))
+
+	for i, filter := range q.filters {
+		if strings.Contains(filter, "?") {
+			conditions = append(conditions, strings.Replace(filter, "?", fmt.Sprintf("$%d", argPos), 1))
+			args = append(args, q.filterArgs[i])
+			argPos++
+			continue
+		}
+		conditions = append(conditions, filter)
+	}
+
+	if len(q.after) > 0 {
+		columns := q.columns()
+		placeholders := make([]string, len(q.after))
+		for i, value := range q.after {
+			placeholders[i] = fmt.Sprintf("$%d", argPos)
+			args = append(args, value)
+			argPos++
+		}
+
+		branches := make([]string, 0, len(columns))
+		for i, column := range columns {
+			parts := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				parts = append(parts, fmt.Sprintf("%s = %s", columns[j].expr, placeholders[j]))
+			}
+			op := ">"
+			if column.desc {
+				op = "<"
+			}
+			parts = append(parts, fmt.Sprintf("%s %s %s", column.expr, op, placeholders[i]))
+			branches = append(branches, "("+strings.Join(parts, " AND ")+")")
+		}
+		conditions = append(conditions, "("+strings.Join(branches, " OR ")+")")
+	}
+
+	return conditions, args
+}
+
+func (q Query) OrderBy() string {
+	columns := q.columns()
+	parts := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column.desc {
+			parts = append(parts, column.expr+" DESC")
+		} else {
+			parts = append(parts, column.expr+" ASC")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (q Query) TotalColumn() string {
+	if q.keyset {
+		return "0"
+	}
+	return "COUNT(*) OVER()"
+}
+
+func (q Query) NeedsCount(rows int) bool {
+	return !q.keyset && rows == 0 && q.Offset > 0
+}
+
+func (q Query) Window() (int, int) {
+	if q.keyset {
+		return q.Limit + 1, 0
+	}
+	return q.Limit, q.Offset
+}
+
+func Finish[T any](q Query, items []T, total int, value func(T, string) any) ([]T, Page, error) {
+	if !q.keyset {
+		return items, Page{Total: total}, nil
+	}
+	if len(items) <= q.Limit {
+		return items, Page{}, nil
+	}
+
+	items = items[:q.Limit]
+	last := items[len(items)-1]
+	columns := q.columns()
+	token := cursorToken{Sort: q.SortString(), Values: make([]string, len(columns))}
+	for i, column := range columns {
+		token.Values[i] = formatValue(value(last, column.field))
+	}
+
+	raw, err := json.Marshal(token)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return items, Page{NextCursor: base64.RawURLEncoding.EncodeToString(raw)}, nil
+}
+
+func NewResponse(data any, page Page, q Query) any {
+	if !q.keyset {
+		return pagination.NewResponse(data, page.Total, q.Params)
+	}
+
+	var next *string
+	if page.NextCursor != "" {
+		next = &page.NextCursor
+	}
+	return cursorResponse{
+		Data: data,
+		Pagination: cursorPagination{
+			Limit:      q.Limit,
+			Search:     q.Search,
+			Sort:       q.SortString(),
+			NextCursor: next,
+		},
+	}
+}
+
+type cursorPagination struct {
+	Limit      int     `json:"limit"`
+	Search     string  `json:"search"`
+	Sort       string  `json:"sort"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+type cursorResponse struct {
+	Data       any              `json:"data"`
+	Pagination cursorPagination `json:"pagination"`
+}
+
+type orderColumn struct {
+	field string
+	expr  string
+	desc  bool
+}
+
+func (q Query) columns() []orderColumn {
+	columns := make([]orderColumn, 0, len(q.Sorts)+1)
+	desc := false
+	for _, sort := range q.Sorts {
+		columns = append(columns, orderColumn{field: sort.Field, expr: q.schema.Sorts[sort.Field], desc: sort.Desc})
+		desc = sort.Desc
+		if sort.Field == q.schema.Key {
+			return columns
+		}
+	}
+	return append(columns, orderColumn{field: q.schema.Key, expr: q.schema.Sorts[q.schema.Key], desc: desc})
+}
+
+func (s *Schema) parseSort(raw string) ([]Sort, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) > maxSortFields {
+		return nil, ErrInvalidSort
+	}
+
+	seen := make(map[string]bool, len(parts))
+	sorts := make([]Sort, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		sort := Sort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := s.Sorts[sort.Field]; !ok || seen[sort.Field] {
+			return nil, ErrInvalidSort
+		}
+		seen[sort.Field] = true
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+func (q *Query) parseFilter(c *gin.Context, name string, filter Filter) error {
+	switch filter.Kind {
+	case Bool, Presence:
+		raw := c.Query(name)
+		if raw == "" {
+			return nil
+		}
+		val, err := strconv.ParseBool(raw)
+		if err != nil {
+			return ErrInvalidFilter
+		}
+		if filter.Kind == Bool {
+			q.addFilter(filter.Column+" = ?", val)
+		} else if val {
+			q.addFilter(fmt.Sprintf("NULLIF(%s, '') IS NOT NULL", filter.Column), nil)
+		} else {
+			q.addFilter(fmt.Sprintf("NULLIF(%s, '') IS NULL", filter.Column), nil)
+		}
+	case TimeRange:
+		if raw := c.Query(name + "_from"); raw != "" {
+			from, _, err := parseTime(raw)
+			if err != nil {
+				return ErrInvalidFilter
+			}
+			q.addFilter(filter.Column+" >= ?", from)
+		}
+		if raw := c.Query(name + "_to"); raw != "" {
+			to, dateOnly, err := parseTime(raw)
+			if err != nil {
+				return ErrInvalidFilter
+			}
+			if dateOnly {
+				q.addFilter(filter.Column+" < ?", to.AddDate(0, 0, 1))
+			} else {
+				q.addFilter(filter.Column+" <= ?", to)
+			}
+		}
+	case NumberRange:
+		if raw := c.Query(name + "_min"); raw != "" {
+			min, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return ErrInvalidFilter
+			}
+			q.addFilter(filter.Column+" >= ?", min)
+		}
+		if raw := c.Query(name + "_max"); raw != "" {
+			max, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return ErrInvalidFilter
+			}
+			q.addFilter(filter.Column+" <= ?", max)
+		}
+	case Equal:
+		if raw := c.Query(name); raw != "" {
+			q.addFilter(filter.Column+" = ?", raw)
+		}
+	}
+	return nil
+}
+
+func (q *Query) addFilter(condition string, arg any) {
+	q.filters = append(q.filters, condition)
+	q.filterArgs = append(q.filterArgs, arg)
+}
+
+func (q *Query) decodeCursor(raw string) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	var token cursorToken
+	if err := json.Unmarshal(decoded, &token); err != nil {
+		return ErrInvalidCursor
+	}
+
+	sorts, err := q.schema.parseSort(token.Sort)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	q.Sorts = sorts
+	if len(token.Values) != len(q.columns()) {
+		return ErrInvalidCursor
+	}
+	q.after = token.Values
+	return nil
+}
+
+func parseTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	return t, true, err
+}
+
+func formatValue(val any) string {
+	switch v := val.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,244 @@
+package queryspec
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testRow struct {
+	ID        int64
+	CreatedAt time.Time
+	Name      string
+}
+
+var testSchema = &Schema{
+	Sorts: map[string]string{
+		"id":         "t.id",
+		"created_at": "t.created_at",
+		"name":       "t.name",
+	},
+	Filters: map[string]Filter{
+		"active":     {Kind: Bool, Column: "t.active"},
+		"telegram":   {Kind: Presence, Column: "t.telegram"},
+		"created_at": {Kind: TimeRange, Column: "t.created_at"},
+		"amount":     {Kind: NumberRange, Column: "t.amount"},
+		"provider":   {Kind: Equal, Column: "t.provider"},
+	},
+	Default: []Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
+func testRowValue(row testRow, field string) any {
+	switch field {
+	case "created_at":
+		return row.CreatedAt
+	case "name":
+		return row.Name
+	default:
+		return row.ID
+	}
+}
+
+func parseQuery(t *testing.T, rawQuery string) (Query, error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?"+rawQuery, nil)
+	return Parse(c, testSchema)
+}
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		query      string
+		conditions []string
+		args       []any
+		err        error
+	}{
+		{query: ""},
+		{query: "active=true", conditions: []string{"t.active = $3"}, args: []any{true}},
+		{query: "active=maybe", err: ErrInvalidFilter},
+		{query: "telegram=true", conditions: []string{"NULLIF(t.telegram, '') IS NOT NULL"}},
+		{query: "telegram=false", conditions: []string{"NULLIF(t.telegram, '') IS NULL"}},
+		{
+			query:      "created_at_from=2026-01-01&created_at_to=2026-01-31",
+			conditions: []string{"t.created_at >= $3", "t.created_at < $4"},
+			args:       []any{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			query:      "created_at_to=2026-01-31T12:00:00Z",
+			conditions: []string{"t.created_at <= $3"},
+			args:       []any{time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)},
+		},
+		{query: "created_at_from=yesterday", err: ErrInvalidFilter},
+		{query: "amount_min=10&amount_max=20.5", conditions: []string{"t.amount >= $3", "t.amount <= $4"}, args: []any{10.0, 20.5}},
+		{query: "amount_max=lots", err: ErrInvalidFilter},
+		{query: "provider=midtrans", conditions: []string{"t.provider = $3"}, args: []any{"midtrans"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(t, tt.query)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			conditions, args := q.Conditions(3)
+			if len(conditions) != len(tt.conditions) || (len(conditions) > 0 && !reflect.DeepEqual(conditions, tt.conditions)) {
+				t.Errorf("conditions = %q, want %q", conditions, tt.conditions)
+			}
+			if len(args) != len(tt.args) || (len(args) > 0 && !reflect.DeepEqual(args, tt.args)) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		query   string
+		orderBy string
+		sort    string
+		err     error
+	}{
+		{query: "", orderBy: "t.id DESC", sort: "-id"},
+		{query: "sort=name", orderBy: "t.name ASC, t.id ASC", sort: "name"},
+		{query: "sort=-created_at", orderBy: "t.created_at DESC, t.id DESC", sort: "-created_at"},
+		{query: "sort=id,name", orderBy: "t.id ASC", sort: "id,name"},
+		{query: "sort=name,-id", orderBy: "t.name ASC, t.id DESC", sort: "name,-id"},
+		{query: "sort=password", err: ErrInvalidSort},
+		{query: "sort=name,name", err: ErrInvalidSort},
+		{query: "sort=id,name,created_at,-id", err: ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(t, tt.query)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if got := q.OrderBy(); got != tt.orderBy {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.orderBy)
+			}
+			if got := q.SortString(); got != tt.sort {
+				t.Errorf("SortString() = %q, want %q", got, tt.sort)
+			}
+		})
+	}
+}
+
+func TestOffsetPaging(t *testing.T) {
+	tests := []struct {
+		query      string
+		rows       int
+		limit      int
+		offset     int
+		needsCount bool
+	}{
+		{query: "", rows: 10, limit: 10, offset: 0},
+		{query: "page=1", rows: 0, limit: 10, offset: 0},
+		{query: "page=3&limit=20", rows: 5, limit: 20, offset: 40},
+		{query: "page=9&limit=20", rows: 0, limit: 20, offset: 160, needsCount: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(t, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Keyset() {
+				t.Fatal("offset query reported keyset")
+			}
+			if got := q.TotalColumn(); got != "COUNT(*) OVER()" {
+				t.Errorf("TotalColumn() = %q", got)
+			}
+			limit, offset := q.Window()
+			if limit != tt.limit || offset != tt.offset {
+				t.Errorf("Window() = %d, %d; want %d, %d", limit, offset, tt.limit, tt.offset)
+			}
+			if got := q.NeedsCount(tt.rows); got != tt.needsCount {
+				t.Errorf("NeedsCount(%d) = %v, want %v", tt.rows, got, tt.needsCount)
+			}
+
+			rows := make([]testRow, tt.rows)
+			_, page, err := Finish(q, rows, 42, testRowValue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != 42 || page.NextCursor != "" {
+				t.Errorf("page = %+v", page)
+			}
+		})
+	}
+}
+
+func TestKeysetPaging(t *testing.T) {
+	base := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	rows := []testRow{
+		{ID: 9, CreatedAt: base.Add(3 * time.Hour)},
+		{ID: 8, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 7, CreatedAt: base.Add(2 * time.Hour)},
+	}
+
+	q, err := parseQuery(t, "sort=-created_at&limit=2&page=5&cursor=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Keyset() || q.TotalColumn() != "0" || q.NeedsCount(0) {
+		t.Fatalf("unexpected keyset query %+v", q)
+	}
+	if limit, offset := q.Window(); limit != 3 || offset != 0 {
+		t.Fatalf("Window() = %d, %d; want 3, 0", limit, offset)
+	}
+
+	items, page, err := Finish(q, rows, 0, testRowValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page = %d items, cursor %q", len(items), page.NextCursor)
+	}
+
+	next, err := parseQuery(t, "limit=2&cursor="+page.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next.SortString(); got != "-created_at" {
+		t.Errorf("cursor sort = %q, want -created_at", got)
+	}
+	conditions, args := next.Conditions(1)
+	wantConditions := []string{"((t.created_at < $1) OR (t.created_at = $1 AND t.id < $2))"}
+	if !reflect.DeepEqual(conditions, wantConditions) {
+		t.Errorf("conditions = %q, want %q", conditions, wantConditions)
+	}
+	wantArgs := []any{"2026-05-01T02:00:00Z", "8"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+
+	items, page, err = Finish(next, rows[2:], 0, testRowValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || page.NextCursor != "" {
+		t.Errorf("last page = %d items, cursor %q", len(items), page.NextCursor)
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	for _, cursor := range []string{"!!!", "bm90LWpzb24", "eyJzIjoicGFzc3dvcmQiLCJ2IjpbXX0", "eyJzIjoiLWlkIiwidiI6W119"} {
+		if _, err := parseQuery(t, "cursor="+cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %q: error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"koalbot_api/internal/model"
+)
+
+type BalanceHistoryRepository struct {
+	db *sql.DB
+}
+
+func NewBalanceHistoryRepository(db *sql.DB) *BalanceHistoryRepository {
+	return &BalanceHistoryRepository{db: db}
+}
+
+func (r *BalanceHistoryRepository) Series(ctx context.Context, penggunaID int64, from, to time.Time, step time.Duration) ([]model.BalancePoint, error) {
+	ctx, span := startSpan(ctx, "BalanceHistoryRepository.Series")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			to_timestamp(floor(extract(epoch FROM captured_at) / $4) * $4) AS bucket,
+			(array_agg(balance ORDER BY captured_at, balance_version))[1],
+			MAX(balance),
+			MIN(balance),
+			(array_agg(balance ORDER BY captured_at DESC, balance_version DESC))[1],
+			COUNT(*)
+		FROM t_pengguna_balance_history
+		WHERE pengguna_id = $1 AND captured_at >= $2 AND captured_at < $3
+		GROUP BY 1
+		ORDER BY 1
+	`, penggunaID, from, to, int64(step/time.Second))
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	points := make([]model.BalancePoint, 0)
+	for rows.Next() {
+		var point model.BalancePoint
+		if err := rows.Scan(&point.Bucket, &point.Open, &point.High, &point.Low, &point.Close, &point.Samples); err != nil {
+			return nil, spanError(span, err)
+		}
+		point.Bucket = point.Bucket.UTC()
+		points = append(points, point)
+	}
+	return points, spanError(span, rows.Err())
+}
@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+)
+
+var ErrTemplateNameConflict = errors.New("template_name_conflict")
+
+var PenggunaConfigQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"version":    "version",
+		"created_at": "created_at",
+	},
+	Filters: map[string]queryspec.Filter{
+		"source":     {Kind: queryspec.Equal, Column: "source"},
+		"created_at": {Kind: queryspec.TimeRange, Column: "created_at"},
+	},
+	Default: []queryspec.Sort{{Field: "version", Desc: true}},
+	Key:     "version",
+}
+
+const templateColumns = `id, name, COALESCE(description, ''), config, created_at, COALESCE(created_by, ''), updated_at, COALESCE(updated_by, '')`
+
+const penggunaConfigColumns = `id, pengguna_id, version, config, source, template_id, rollback_of, COALESCE(note, ''), COALESCE(created_by, ''), created_at`
+
+type BotConfigRepository struct {
+	db *sql.DB
+}
+
+func NewBotConfigRepository(db *sql.DB) *BotConfigRepository {
+	return &BotConfigRepository{db: db}
+}
+
+func (r *BotConfigRepository) ListTemplates(ctx context.Context) ([]model.BotConfigTemplate, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.ListTemplates")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM m_bot_config_template ORDER BY name`)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	templates := make([]model.BotConfigTemplate, 0)
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+		templates = append(templates, template)
+	}
+	return templates, spanError(span, rows.Err())
+}
+
+func (r *BotConfigRepository) GetTemplate(ctx context.Context, id int64) (model.BotConfigTemplate, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.GetTemplate")
+	defer span.End()
+
+	template, err := scanTemplate(r.db.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM m_bot_config_template WHERE id = $1`, id))
+	return template, spanError(span, err)
+}
+
+func (r *BotConfigRepository) CreateTemplate(ctx context.Context, template model.BotConfigTemplate) (model.BotConfigTemplate, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.CreateTemplate")
+	defer span.End()
+
+	created, err := scanTemplate(r.db.QueryRowContext(ctx, `
+		INSERT INTO m_bot_config_template (name, description, config, created_by)
+		VALUES ($1, NULLIF($2, ''), $3::jsonb, $4)
+		RETURNING `+templateColumns,
+		template.Name, template.Description, string(template.Config), template.CreatedBy))
+	return created, spanError(span, templateConflict(err))
+}
+
+func (r *BotConfigRepository) UpdateTemplate(ctx context.Context, template model.BotConfigTemplate) (model.BotConfigTemplate, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.UpdateTemplate")
+	defer span.End()
+
+	updated, err := scanTemplate(r.db.QueryRowContext(ctx, `
+		UPDATE m_bot_config_template
+		SET name = $2, description = NULLIF($3, ''), config = $4::jsonb, updated_at = NOW(), updated_by = $5
+		WHERE id = $1
+		RETURNING `+templateColumns,
+		template.ID, template.Name, template.Description, string(template.Config), template.UpdatedBy))
+	return updated, spanError(span, templateConflict(err))
+}
+
+func (r *BotConfigRepository) DeleteTemplate(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "BotConfigRepository.DeleteTemplate")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM m_bot_config_template WHERE id = $1`, id)
+	return spanError(span, affectedOrNoRows(res, err))
+}
+
+func (r *BotConfigRepository) AssignTemplate(ctx context.Context, templateID int64, ids []int64, createdBy string) (int64, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.AssignTemplate")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		SELECT id FROM m_pengguna
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE
+	`, pq.Array(ids)); err != nil {
+		return 0, spanError(span, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO t_pengguna_config (pengguna_id, version, config, source, template_id, created_by)
+		SELECT m.id,
+			COALESCE((SELECT MAX(c.version) FROM t_pengguna_config c WHERE c.pengguna_id = m.id), 0) + 1,
+			t.config, 'template', t.id, $3
+		FROM m_pengguna m
+		JOIN m_bot_config_template t ON t.id = $2
+		WHERE m.id = ANY($1) AND m.deleted_at IS NULL
+	`, pq.Array(ids), templateID, createdBy)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	assigned, err := res.RowsAffected()
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	return assigned, spanError(span, tx.Commit())
+}
+
+func (r *BotConfigRepository) Current(ctx context.Context, penggunaID int64) (model.PenggunaConfig, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.Current")
+	defer span.End()
+
+	cfg, err := scanPenggunaConfig(r.db.QueryRowContext(ctx, `
+		SELECT `+penggunaConfigColumns+`
+		FROM t_pengguna_config
+		WHERE pengguna_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`, penggunaID))
+	return cfg, spanError(span, err)
+}
+
+func (r *BotConfigRepository) GetVersion(ctx context.Context, penggunaID int64, version int) (model.PenggunaConfig, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.GetVersion")
+	defer span.End()
+
+	cfg, err := scanPenggunaConfig(r.db.QueryRowContext(ctx, `
+		SELECT `+penggunaConfigColumns+`
+		FROM t_pengguna_config
+		WHERE pengguna_id = $1 AND version = $2
+	`, penggunaID, version))
+	return cfg, spanError(span, err)
+}
+
+func (r *BotConfigRepository) History(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaConfig, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.History")
+	defer span.End()
+
+	conditions := []string{"pengguna_id = $1"}
+	args := []any{penggunaID}
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
+
+	limit, offset := q.Window()
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM t_pengguna_config
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, penggunaConfigColumns, q.TotalColumn(), strings.Join(conditions, " AND "), q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	var total int
+	history := make([]model.PenggunaConfig, 0)
+	for rows.Next() {
+		cfg, err := scanPenggunaConfig(rows, &total)
+		if err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		history = append(history, cfg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(history)) {
+		if total, err = countTotal(ctx, r.db, "t_pengguna_config", strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	history, page, err := queryspec.Finish(q, history, total, penggunaConfigSortValue)
+	return history, page, spanError(span, err)
+}
+
+func (r *BotConfigRepository) Save(ctx context.Context, cfg model.PenggunaConfig) (model.PenggunaConfig, error) {
+	ctx, span := startSpan(ctx, "BotConfigRepository.Save")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.PenggunaConfig{}, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	var locked int64
+	if err := tx.QueryRowContext(ctx, `
+		SELECT id FROM m_pengguna WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, cfg.PenggunaID).Scan(&locked); err != nil {
+		return model.PenggunaConfig{}, spanError(span, err)
+	}
+
+	saved, err := scanPenggunaConfig(tx.QueryRowContext(ctx, `
+		INSERT INTO t_pengguna_config (pengguna_id, version, config, source, template_id, rollback_of, note, created_by)
+		SELECT $1::bigint, COALESCE(MAX(version), 0) + 1, $2::jsonb, $3::varchar, $4::bigint, $5::integer, NULLIF($6::text, ''), $7::varchar
+		FROM t_pengguna_config
+		WHERE pengguna_id = $1
+		RETURNING `+penggunaConfigColumns,
+		cfg.PenggunaID, string(cfg.Config), cfg.Source, cfg.TemplateID, cfg.RollbackOf, cfg.Note, cfg.CreatedBy))
+	if err != nil {
+		return model.PenggunaConfig{}, spanError(span, err)
+	}
+
+	return saved, spanError(span, tx.Commit())
+}
+
+func scanTemplate(row rowScanner) (model.BotConfigTemplate, error) {
+	var template model.BotConfigTemplate
+	var config []byte
+	var updatedAt sql.NullTime
+	if err := row.Scan(
+		&template.ID,
+		&template.Name,
+		&template.Description,
+		&config,
+		&template.CreatedAt,
+		&template.CreatedBy,
+		&updatedAt,
+		&template.UpdatedBy,
+	); err != nil {
+		return model.BotConfigTemplate{}, err
+	}
+	template.Config = config
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		template.UpdatedAt = &val
+	}
+	return template, nil
+}
+
+func scanPenggunaConfig(row rowScanner, extra ...any) (model.PenggunaConfig, error) {
+	var cfg model.PenggunaConfig
+	var config []byte
+	var templateID, rollbackOf sql.NullInt64
+	dest := append([]any{
+		&cfg.ID,
+		&cfg.PenggunaID,
+		&cfg.Version,
+		&config,
+		&cfg.Source,
+		&templateID,
+		&rollbackOf,
+		&cfg.Note,
+		&cfg.CreatedBy,
+		&cfg.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return model.PenggunaConfig{}, err
+	}
+	cfg.Config = config
+	if templateID.Valid {
+		val := templateID.Int64
+		cfg.TemplateID = &val
+	}
+	if rollbackOf.Valid {
+		val := int(rollbackOf.Int64)
+		cfg.RollbackOf = &val
+	}
+	return cfg, nil
+}
+
+func templateConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrTemplateNameConflict
+	}
+	return err
+}
+
+func penggunaConfigSortValue(cfg model.PenggunaConfig, field string) any {
+	if field == "created_at" {
+		return cfg.CreatedAt
+	}
+	return cfg.Version
+}
@@ -0,0 +1,11 @@
+package repository
+
+type BulkMasterPenggunaFilter struct {
+	Search string
+	Jenis  string
+}
+
+type BulkMasterPenggunaResult struct {
+	ID     int64
+	Status string
+}
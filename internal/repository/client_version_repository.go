@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"koalbot_api/internal/model"
+)
+
+const clientVersionColumns = `device_type, COALESCE(min_version, ''), COALESCE(recommended_version, ''), COALESCE(download_url, ''), updated_at, COALESCE(updated_by, '')`
+
+type ClientVersionRepository struct {
+	db *sql.DB
+}
+
+func NewClientVersionRepository(db *sql.DB) *ClientVersionRepository {
+	return &ClientVersionRepository{db: db}
+}
+
+func (r *ClientVersionRepository) List(ctx context.Context) ([]model.ClientVersionPolicy, error) {
+	ctx, span := startSpan(ctx, "ClientVersionRepository.List")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+clientVersionColumns+` FROM m_client_version ORDER BY device_type`)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	policies := make([]model.ClientVersionPolicy, 0)
+	for rows.Next() {
+		policy, err := scanClientVersion(rows)
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, spanError(span, rows.Err())
+}
+
+func (r *ClientVersionRepository) Get(ctx context.Context, deviceType string) (model.ClientVersionPolicy, error) {
+	ctx, span := startSpan(ctx, "ClientVersionRepository.Get")
+	defer span.End()
+
+	policy, err := scanClientVersion(r.db.QueryRowContext(ctx, `
+		SELECT `+clientVersionColumns+`
+		FROM m_client_version
+		WHERE device_type = $1
+	`, deviceType))
+	return policy, spanError(span, err)
+}
+
+func (r *ClientVersionRepository) Upsert(ctx context.Context, policy model.ClientVersionPolicy) (model.ClientVersionPolicy, error) {
+	ctx, span := startSpan(ctx, "ClientVersionRepository.Upsert")
+	defer span.End()
+
+	saved, err := scanClientVersion(r.db.QueryRowContext(ctx, `
+		INSERT INTO m_client_version (device_type, min_version, recommended_version, download_url, updated_by)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5)
+		ON CONFLICT (device_type) DO UPDATE SET
+			min_version = EXCLUDED.min_version,
+			recommended_version = EXCLUDED.recommended_version,
+			download_url = EXCLUDED.download_url,
+			updated_at = NOW(),
+			updated_by = EXCLUDED.updated_by
+		RETURNING `+clientVersionColumns,
+		policy.DeviceType, policy.MinVersion, policy.RecommendedVersion, policy.DownloadURL, policy.UpdatedBy))
+	return saved, spanError(span, err)
+}
+
+func (r *ClientVersionRepository) Delete(ctx context.Context, deviceType string) error {
+	ctx, span := startSpan(ctx, "ClientVersionRepository.Delete")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM m_client_version WHERE device_type = $1`, deviceType)
+	return spanError(span, affectedOrNoRows(res, err))
+}
+
+func scanClientVersion(row rowScanner) (model.ClientVersionPolicy, error) {
+	var policy model.ClientVersionPolicy
+	err := row.Scan(
+		&policy.DeviceType,
+		&policy.MinVersion,
+		&policy.RecommendedVersion,
+		&policy.DownloadURL,
+		&policy.UpdatedAt,
+		&policy.UpdatedBy,
+	)
+	return policy, err
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"koalbot_api/internal/model"
+)
+
+type DashboardRepository struct {
+	db *sql.DB
+}
+
+func NewDashboardRepository(db *sql.DB) *DashboardRepository {
+	return &DashboardRepository{db: db}
+}
+
+func (r *DashboardRepository) Summary(ctx context.Context) (model.DashboardSummary, error) {
+	ctx, span := startSpan(ctx, "DashboardRepository.Summary")
+	defer span.End()
+
+	var summary model.DashboardSummary
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE m.active),
+			COALESCE(SUM(d.balance) FILTER (WHERE m.active), 0),
+			COALESCE(AVG(d.balance) FILTER (WHERE m.active), 0),
+			COALESCE(SUM(d.deposits_sum) FILTER (WHERE m.active), 0),
+			COALESCE(AVG(d.deposits_sum) FILTER (WHERE m.active), 0)
+		FROM m_pengguna m
+		LEFT JOIN LATERAL (
+			SELECT balance, deposits_sum
+			FROM t_pengguna_detail
+			WHERE pengguna_id = m.id
+			ORDER BY id DESC
+			LIMIT 1
+		) d ON TRUE
+		WHERE m.deleted_at IS NULL
+	`).Scan(
+		&summary.Total,
+		&summary.Active,
+		&summary.TotalBalance,
+		&summary.AvgBalance,
+		&summary.TotalDeposit,
+		&summary.AvgDeposit,
+	)
+	if err != nil {
+		return model.DashboardSummary{}, spanError(span, err)
+	}
+	summary.Inactive = summary.Total - summary.Active
+
+	return summary, nil
+}
+
+func (r *DashboardRepository) Breakdown(ctx context.Context, from, to time.Time) (model.DashboardBreakdown, error) {
+	ctx, span := startSpan(ctx, "DashboardRepository.Breakdown")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			GROUPING(m.jenis),
+			m.jenis::text,
+			d.country,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE m.active)
+		FROM m_pengguna m
+		LEFT JOIN LATERAL (
+			SELECT country
+			FROM t_pengguna_detail
+			WHERE pengguna_id = m.id
+			ORDER BY id DESC
+			LIMIT 1
+		) d ON TRUE
+		WHERE m.deleted_at IS NULL
+			AND m.created_at >= $1 AND m.created_at < $2
+		GROUP BY GROUPING SETS ((m.jenis), (d.country))
+		ORDER BY 4 DESC
+	`, from, to)
+	if err != nil {
+		return model.DashboardBreakdown{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	breakdown := model.DashboardBreakdown{
+		Jenis:   make([]model.DashboardGroup, 0),
+		Country: make([]model.DashboardGroup, 0),
+	}
+	for rows.Next() {
+		var jenisGrouped int
+		var jenis sql.NullString
+		var country sql.NullString
+		var group model.DashboardGroup
+		if err := rows.Scan(&jenisGrouped, &jenis, &country, &group.Total, &group.Active); err != nil {
+			return model.DashboardBreakdown{}, spanError(span, err)
+		}
+		if jenisGrouped == 0 {
+			group.Key = jenis.String
+			breakdown.Jenis = append(breakdown.Jenis, group)
+			continue
+		}
+		group.Key = country.String
+		breakdown.Country = append(breakdown.Country, group)
+	}
+	if err := rows.Err(); err != nil {
+		return model.DashboardBreakdown{}, spanError(span, err)
+	}
+
+	return breakdown, nil
+}
+
+func (r *DashboardRepository) Timeseries(ctx context.Context, from, to time.Time, bucket string) ([]model.DashboardPoint, error) {
+	ctx, span := startSpan(ctx, "DashboardRepository.Timeseries")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		WITH buckets AS (
+			SELECT generate_series(
+				date_trunc($3, $1::timestamptz AT TIME ZONE 'UTC'),
+				date_trunc($3, ($2::timestamptz - INTERVAL '1 microsecond') AT TIME ZONE 'UTC'),
+				('1 ' || $3)::interval
+			) AS bucket
+		),
+		registrations AS (
+			SELECT date_trunc($3, created_at AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS total
+			FROM m_pengguna
+			WHERE created_at >= $1 AND created_at < $2
+			GROUP BY 1
+		),
+		activations AS (
+			SELECT date_trunc($3, activated_at AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS total
+			FROM m_pengguna
+			WHERE activated_at >= $1 AND activated_at < $2
+			GROUP BY 1
+		),
+		logins AS (
+			SELECT date_trunc($3, created_at AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS total
+			FROM t_pengguna_login
+			WHERE outcome = 'success' AND created_at >= $1 AND created_at < $2
+			GROUP BY 1
+		)
+		SELECT b.bucket, COALESCE(r.total, 0), COALESCE(a.total, 0), COALESCE(l.total, 0)
+		FROM buckets b
+		LEFT JOIN registrations r ON r.bucket = b.bucket
+		LEFT JOIN activations a ON a.bucket = b.bucket
+		LEFT JOIN logins l ON l.bucket = b.bucket
+		ORDER BY b.bucket
+	`, from, to, bucket)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	points := make([]model.DashboardPoint, 0)
+	for rows.Next() {
+		var point model.DashboardPoint
+		if err := rows.Scan(&point.Bucket, &point.Registrations, &point.Activations, &point.Logins); err != nil {
+			return nil, spanError(span, err)
+		}
+		point.Bucket = point.Bucket.UTC()
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, spanError(span, err)
+	}
+
+	return points, nil
+}
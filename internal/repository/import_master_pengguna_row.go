@@ -0,0 +1,8 @@
+package repository
+
+type ImportMasterPenggunaRow struct {
+	IDPengguna int64
+	Telegram   *string
+	Jenis      *string
+	Active     *bool
+}
@@ -3,12 +3,48 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
 
 	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+)
+
+var (
+	ErrBulkTooLarge       = errors.New("bulk_too_large")
+	ErrIDPenggunaConflict = errors.New("id_pengguna_conflict")
 )
 
+var MasterPenggunaQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":           "id",
+		"id_pengguna":  "id_pengguna",
+		"telegram":     "COALESCE(telegram, '')",
+		"jenis":        "jenis",
+		"active":       "active",
+		"created_at":   "created_at",
+		"balance":      "COALESCE(detail_balance, 0)",
+		"last_login":   "COALESCE(last_login_at, 'epoch'::timestamptz)",
+		"active_until": "COALESCE(active_until, 'infinity'::timestamptz)",
+	},
+	Filters: map[string]queryspec.Filter{
+		"active":       {Kind: queryspec.Bool, Column: "active"},
+		"has_telegram": {Kind: queryspec.Presence, Column: "telegram"},
+		"created_at":   {Kind: queryspec.TimeRange, Column: "created_at"},
+		"balance":      {Kind: queryspec.NumberRange, Column: "COALESCE(detail_balance, 0)"},
+		"country":      {Kind: queryspec.Equal, Column: "detail_country"},
+		"blocked":      {Kind: queryspec.Bool, Column: "COALESCE(detail_blocked, FALSE)"},
+		"last_login":   {Kind: queryspec.TimeRange, Column: "last_login_at"},
+		"active_until": {Kind: queryspec.TimeRange, Column: "active_until"},
+	},
+	Default: []queryspec.Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
 type MasterPenggunaRepository struct {
 	db *sql.DB
 }
@@ -18,16 +54,33 @@ func NewMasterPenggunaRepository(db *sql.DB) *MasterPenggunaRepository {
 }
 
 func (r *MasterPenggunaRepository) Create(ctx context.Context, idPengguna int64, telegram *string, jenis string, active bool) (model.MasterPengguna, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Create")
+	defer span.End()
+
 	var result model.MasterPengguna
 	var telegramVal sql.NullString
+	var updatedAt sql.NullTime
 	if telegram != nil {
 		telegramVal = sql.NullString{String: *telegram, Valid: true}
 	}
 
-	err := r.db.QueryRowContext(ctx, `
-		INSERT INTO m_pengguna (id_pengguna, telegram, jenis, active)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, uuid, id_pengguna, telegram, jenis, active, created_at
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.MasterPengguna{}, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE m_pengguna
+		SET telegram = COALESCE($2, telegram), jenis = $3, active = $4, deleted_at = NULL, updated_at = NOW(),
+			activated_at = CASE WHEN $4 AND NOT active THEN NOW() ELSE activated_at END
+		WHERE id = (
+			SELECT id FROM m_pengguna
+			WHERE id_pengguna = $1 AND deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC
+			LIMIT 1
+		)
+		RETURNING id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at
 	`, idPengguna, telegramVal, jenis, active).Scan(
 		&result.ID,
 		&result.UUID,
@@ -36,29 +89,64 @@ func (r *MasterPenggunaRepository) Create(ctx context.Context, idPengguna int64,
 		&result.Jenis,
 		&result.Active,
 		&result.CreatedAt,
+		&updatedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO m_pengguna (id_pengguna, telegram, jenis, active, activated_at)
+			VALUES ($1, $2, $3, $4, CASE WHEN $4 THEN NOW() END)
+			RETURNING id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at
+		`, idPengguna, telegramVal, jenis, active).Scan(
+			&result.ID,
+			&result.UUID,
+			&result.IDPengguna,
+			&telegramVal,
+			&result.Jenis,
+			&result.Active,
+			&result.CreatedAt,
+			&updatedAt,
+		)
+	}
 	if err != nil {
-		return model.MasterPengguna{}, err
+		return model.MasterPengguna{}, spanError(span, conflictError(err))
 	}
+	if err := refreshSearch(ctx, tx, []int64{result.ID}); err != nil {
+		return model.MasterPengguna{}, spanError(span, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return model.MasterPengguna{}, spanError(span, conflictError(err))
+	}
+
 	if telegramVal.Valid {
 		val := telegramVal.String
 		result.Telegram = &val
 	}
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		result.UpdatedAt = &val
+	}
 
 	return result, nil
 }
 
 func (r *MasterPenggunaRepository) GetByID(ctx context.Context, id int64) (model.MasterPengguna, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.GetByID")
+	defer span.End()
+
 	var result model.MasterPengguna
 	var telegram sql.NullString
 	var updatedAt sql.NullTime
 	var deletedAt sql.NullTime
+	var lastLoginAt sql.NullTime
+	var activeUntil sql.NullTime
+	var detail detailSummaryScan
 
 	err := r.db.QueryRowContext(ctx, `
-		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at, deleted_at
+		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at, deleted_at, last_login_at, active_until, `+detailSummaryColumns+`
 		FROM m_pengguna
+		`+detailSummaryJoin+`
 		WHERE id = $1
-	`, id).Scan(
+	`, id).Scan(append([]any{
 		&result.ID,
 		&result.UUID,
 		&result.IDPengguna,
@@ -68,9 +156,11 @@ func (r *MasterPenggunaRepository) GetByID(ctx context.Context, id int64) (model
 		&result.CreatedAt,
 		&updatedAt,
 		&deletedAt,
-	)
+		&lastLoginAt,
+		&activeUntil,
+	}, detail.dest()...)...)
 	if err != nil {
-		return model.MasterPengguna{}, err
+		return model.MasterPengguna{}, spanError(span, err)
 	}
 
 	if telegram.Valid {
@@ -85,11 +175,23 @@ func (r *MasterPenggunaRepository) GetByID(ctx context.Context, id int64) (model
 		val := deletedAt.Time
 		result.DeletedAt = &val
 	}
+	if lastLoginAt.Valid {
+		val := lastLoginAt.Time
+		result.LastLoginAt = &val
+	}
+	if activeUntil.Valid {
+		val := activeUntil.Time
+		result.ActiveUntil = &val
+	}
+	result.Detail = detail.summary()
 
 	return result, nil
 }
 
 func (r *MasterPenggunaRepository) GetByIDPengguna(ctx context.Context, idPengguna int64) (model.MasterPengguna, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.GetByIDPengguna")
+	defer span.End()
+
 	var result model.MasterPengguna
 	var telegram sql.NullString
 	var updatedAt sql.NullTime
@@ -98,7 +200,7 @@ func (r *MasterPenggunaRepository) GetByIDPengguna(ctx context.Context, idPenggu
 	err := r.db.QueryRowContext(ctx, `
 		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at, deleted_at
 		FROM m_pengguna
-		WHERE id_pengguna = $1
+		WHERE id_pengguna = $1 AND deleted_at IS NULL
 	`, idPengguna).Scan(
 		&result.ID,
 		&result.UUID,
@@ -111,7 +213,7 @@ func (r *MasterPenggunaRepository) GetByIDPengguna(ctx context.Context, idPenggu
 		&deletedAt,
 	)
 	if err != nil {
-		return model.MasterPengguna{}, err
+		return model.MasterPengguna{}, spanError(span, err)
 	}
 
 	if telegram.Valid {
@@ -130,42 +232,256 @@ func (r *MasterPenggunaRepository) GetByIDPengguna(ctx context.Context, idPenggu
 	return result, nil
 }
 
-func (r *MasterPenggunaRepository) List(ctx context.Context, search string, jenis string, limit, offset int) ([]model.MasterPengguna, int, error) {
-	conditions := []string{
-		"deleted_at IS NULL",
-		"($1 = '' OR telegram ILIKE '%' || $1 || '%' OR CAST(id_pengguna AS TEXT) ILIKE '%' || $1 || '%')",
+func (r *MasterPenggunaRepository) GetByUUID(ctx context.Context, uuid string) (model.MasterPengguna, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.GetByUUID")
+	defer span.End()
+
+	var result model.MasterPengguna
+	var telegram sql.NullString
+	var updatedAt sql.NullTime
+	var deletedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at, deleted_at
+		FROM m_pengguna
+		WHERE uuid = $1 AND deleted_at IS NULL
+	`, uuid).Scan(
+		&result.ID,
+		&result.UUID,
+		&result.IDPengguna,
+		&telegram,
+		&result.Jenis,
+		&result.Active,
+		&result.CreatedAt,
+		&updatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return model.MasterPengguna{}, spanError(span, err)
 	}
-	args := []any{search}
-	argPos := 2
 
-	if jenis != "" {
-		conditions = append(conditions, fmt.Sprintf("jenis = $%d", argPos))
-		args = append(args, jenis)
-		argPos++
+	if telegram.Valid {
+		val := telegram.String
+		result.Telegram = &val
+	}
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		result.UpdatedAt = &val
+	}
+	if deletedAt.Valid {
+		val := deletedAt.Time
+		result.DeletedAt = &val
 	}
 
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM m_pengguna WHERE %s", strings.Join(conditions, " AND "))
-	var total int
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, err
+	return result, nil
+}
+
+func (r *MasterPenggunaRepository) GetDeletedByIDPengguna(ctx context.Context, idPengguna int64) (model.MasterPengguna, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.GetDeletedByIDPengguna")
+	defer span.End()
+
+	var result model.MasterPengguna
+	var telegram sql.NullString
+	var updatedAt sql.NullTime
+	var deletedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at, deleted_at
+		FROM m_pengguna
+		WHERE id_pengguna = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT 1
+	`, idPengguna).Scan(
+		&result.ID,
+		&result.UUID,
+		&result.IDPengguna,
+		&telegram,
+		&result.Jenis,
+		&result.Active,
+		&result.CreatedAt,
+		&updatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return model.MasterPengguna{}, spanError(span, err)
+	}
+
+	if telegram.Valid {
+		val := telegram.String
+		result.Telegram = &val
+	}
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		result.UpdatedAt = &val
+	}
+	if deletedAt.Valid {
+		val := deletedAt.Time
+		result.DeletedAt = &val
 	}
 
+	return result, nil
+}
+
+func (r *MasterPenggunaRepository) List(ctx context.Context, q queryspec.Query, jenis string, deleted bool) ([]model.MasterPengguna, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.List")
+	defer span.End()
+
+	conditions, args := listConditions(q.Search, jenis, deleted)
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
+
+	orderBy := q.OrderBy()
+	if q.Search != "" && !q.Sorted() && !q.Keyset() {
+		orderBy = searchRank + " DESC, " + orderBy
+	}
+
+	limit, offset := q.Window()
 	listQuery := fmt.Sprintf(`
-		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at
+		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at, deleted_at, last_login_at, active_until, %s,
+			COALESCE(detail_balance, 0)::text, %s
 		FROM m_pengguna
+		%s
 		WHERE %s
-		ORDER BY id DESC
+		ORDER BY %s
 		LIMIT $%d OFFSET $%d
-	`, strings.Join(conditions, " AND "), argPos, argPos+1)
+	`, detailSummaryColumns, q.TotalColumn(), detailSummaryJoin, strings.Join(conditions, " AND "), orderBy, argPos, argPos+1)
 	args = append(args, limit, offset)
 
 	rows, err := r.db.QueryContext(ctx, listQuery, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, queryspec.Page{}, spanError(span, err)
 	}
 	defer rows.Close()
 
+	var total int
 	items := make([]model.MasterPengguna, 0)
+	balances := make(map[int64]string)
+	for rows.Next() {
+		var item model.MasterPengguna
+		var telegram sql.NullString
+		var updatedAt sql.NullTime
+		var deletedAt sql.NullTime
+		var lastLoginAt sql.NullTime
+		var activeUntil sql.NullTime
+		var detail detailSummaryScan
+		var balance string
+		if err := rows.Scan(append([]any{
+			&item.ID,
+			&item.UUID,
+			&item.IDPengguna,
+			&telegram,
+			&item.Jenis,
+			&item.Active,
+			&item.CreatedAt,
+			&updatedAt,
+			&deletedAt,
+			&lastLoginAt,
+			&activeUntil,
+		}, append(detail.dest(), &balance, &total)...)...); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		if telegram.Valid {
+			val := telegram.String
+			item.Telegram = &val
+		}
+		if updatedAt.Valid {
+			val := updatedAt.Time
+			item.UpdatedAt = &val
+		}
+		if deletedAt.Valid {
+			val := deletedAt.Time
+			item.DeletedAt = &val
+		}
+		if lastLoginAt.Valid {
+			val := lastLoginAt.Time
+			item.LastLoginAt = &val
+		}
+		if activeUntil.Valid {
+			val := activeUntil.Time
+			item.ActiveUntil = &val
+		}
+		item.Detail = detail.summary()
+		balances[item.ID] = balance
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(items)) {
+		if total, err = countTotal(ctx, r.db, "m_pengguna "+detailSummaryJoin, strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	items, page, err := queryspec.Finish(q, items, total, func(item model.MasterPengguna, field string) any {
+		return masterPenggunaSortValue(item, balances[item.ID], field)
+	})
+	return items, page, spanError(span, err)
+}
+
+func (r *MasterPenggunaRepository) Suggest(ctx context.Context, search string, limit int) ([]model.MasterPengguna, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Suggest")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, id_pengguna, telegram, jenis, active, `+detailSummaryColumns+`
+		FROM m_pengguna
+		`+detailSummaryJoin+`
+		WHERE deleted_at IS NULL
+			AND (search_vector @@ to_tsquery('simple', $2) OR search_text % lower($1) OR search_text ILIKE '%' || $1 || '%')
+		ORDER BY `+searchRank+` DESC, id DESC
+		LIMIT $3
+	`, search, prefixQuery(search), limit)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	items := make([]model.MasterPengguna, 0, limit)
+	for rows.Next() {
+		var item model.MasterPengguna
+		var telegram sql.NullString
+		var detail detailSummaryScan
+		if err := rows.Scan(append([]any{
+			&item.ID,
+			&item.IDPengguna,
+			&telegram,
+			&item.Jenis,
+			&item.Active,
+		}, detail.dest()...)...); err != nil {
+			return nil, spanError(span, err)
+		}
+		if telegram.Valid {
+			val := telegram.String
+			item.Telegram = &val
+		}
+		item.Detail = detail.summary()
+		items = append(items, item)
+	}
+	return items, spanError(span, rows.Err())
+}
+
+func (r *MasterPenggunaRepository) Export(ctx context.Context, search string, jenis string, fn func(model.MasterPengguna) error) error {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Export")
+	defer span.End()
+
+	conditions, args := listConditions(search, jenis, false)
+	query := fmt.Sprintf(`
+		SELECT id, uuid, id_pengguna, telegram, jenis, active, created_at, updated_at
+		FROM m_pengguna
+		WHERE %s
+		ORDER BY id DESC
+	`, strings.Join(conditions, " AND "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer rows.Close()
+
 	for rows.Next() {
 		var item model.MasterPengguna
 		var telegram sql.NullString
@@ -180,7 +496,7 @@ func (r *MasterPenggunaRepository) List(ctx context.Context, search string, jeni
 			&item.CreatedAt,
 			&updatedAt,
 		); err != nil {
-			return nil, 0, err
+			return spanError(span, err)
 		}
 		if telegram.Valid {
 			val := telegram.String
@@ -190,16 +506,248 @@ func (r *MasterPenggunaRepository) List(ctx context.Context, search string, jeni
 			val := updatedAt.Time
 			item.UpdatedAt = &val
 		}
-		items = append(items, item)
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return spanError(span, rows.Err())
+}
+
+func (r *MasterPenggunaRepository) ExistingIDPengguna(ctx context.Context, ids []int64) (map[int64]bool, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.ExistingIDPengguna")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id_pengguna
+		FROM m_pengguna
+		WHERE id_pengguna = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	existing := make(map[int64]bool, len(ids))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, spanError(span, err)
+		}
+		existing[id] = true
 	}
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, spanError(span, err)
 	}
+	return existing, nil
+}
 
-	return items, total, nil
+func (r *MasterPenggunaRepository) Import(ctx context.Context, rows []ImportMasterPenggunaRow) error {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Import")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	revive, err := tx.PrepareContext(ctx, `
+		UPDATE m_pengguna SET
+			telegram = COALESCE($2::varchar, telegram),
+			jenis = COALESCE($3::e_jenis_pengguna, jenis),
+			active = COALESCE($4::boolean, active),
+			activated_at = CASE WHEN COALESCE($4::boolean, active) AND NOT active THEN NOW() ELSE activated_at END,
+			deleted_at = NULL,
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM m_pengguna
+			WHERE id_pengguna = $1 AND deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC
+			LIMIT 1
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM m_pengguna WHERE id_pengguna = $1 AND deleted_at IS NULL
+		)
+		RETURNING id
+	`)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer revive.Close()
+
+	upsert, err := tx.PrepareContext(ctx, `
+		INSERT INTO m_pengguna (id_pengguna, telegram, jenis, active, activated_at)
+		VALUES (
+			$1, $2::varchar, COALESCE($3::e_jenis_pengguna, 'stockity'), COALESCE($4::boolean, FALSE),
+			CASE WHEN COALESCE($4::boolean, FALSE) THEN NOW() END
+		)
+		ON CONFLICT (id_pengguna) WHERE deleted_at IS NULL DO UPDATE SET
+			telegram = COALESCE($2::varchar, m_pengguna.telegram),
+			jenis = COALESCE($3::e_jenis_pengguna, m_pengguna.jenis),
+			active = COALESCE($4::boolean, m_pengguna.active),
+			activated_at = CASE
+				WHEN COALESCE($4::boolean, m_pengguna.active) AND NOT m_pengguna.active THEN NOW()
+				ELSE m_pengguna.activated_at
+			END,
+			updated_at = NOW()
+		RETURNING id
+	`)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer upsert.Close()
+
+	ids := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		args := []any{row.IDPengguna, nullableString(row.Telegram), nullableString(row.Jenis), nullableBool(row.Active)}
+		var id int64
+		err := revive.QueryRowContext(ctx, args...).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = upsert.QueryRowContext(ctx, args...).Scan(&id)
+		}
+		if err != nil {
+			return spanError(span, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := refreshSearch(ctx, tx, ids); err != nil {
+		return spanError(span, err)
+	}
+
+	return spanError(span, tx.Commit())
+}
+
+func (r *MasterPenggunaRepository) Count(ctx context.Context, search string, jenis string) (int, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Count")
+	defer span.End()
+
+	conditions, args := listConditions(search, jenis, false)
+	query := fmt.Sprintf("SELECT COUNT(*) FROM m_pengguna WHERE %s", strings.Join(conditions, " AND "))
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, spanError(span, err)
+	}
+	return total, nil
 }
 
-func (r *MasterPenggunaRepository) Update(ctx context.Context, id int64, idPengguna *int64, telegram *string, jenis *string, active *bool) error {
+func (r *MasterPenggunaRepository) Bulk(ctx context.Context, action string, ids []int64, filter *BulkMasterPenggunaFilter, maxRows int) ([]BulkMasterPenggunaResult, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Bulk")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	var rows *sql.Rows
+	if filter != nil {
+		conditions, args := listConditions(filter.Search, filter.Jenis, false)
+		query := fmt.Sprintf(`
+			SELECT id, active
+			FROM m_pengguna
+			WHERE %s
+			ORDER BY id
+			LIMIT $%d
+			FOR UPDATE
+		`, strings.Join(conditions, " AND "), len(args)+1)
+		rows, err = tx.QueryContext(ctx, query, append(args, maxRows+1)...)
+	} else {
+		rows, err = tx.QueryContext(ctx, `
+			SELECT id, active
+			FROM m_pengguna
+			WHERE id = ANY($1) AND deleted_at IS NULL
+			ORDER BY id
+			FOR UPDATE
+		`, pq.Array(ids))
+	}
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+
+	current := map[int64]bool{}
+	found := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		var active bool
+		if err := rows.Scan(&id, &active); err != nil {
+			rows.Close()
+			return nil, spanError(span, err)
+		}
+		current[id] = active
+		found = append(found, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, spanError(span, err)
+	}
+	if len(found) > maxRows {
+		return nil, spanError(span, ErrBulkTooLarge)
+	}
+
+	targets := make([]int64, 0, len(found))
+	for _, id := range found {
+		if action == "delete" || (action == "activate") != current[id] {
+			targets = append(targets, id)
+		}
+	}
+
+	if len(targets) > 0 {
+		var query string
+		switch action {
+		case "activate":
+			query = `
+				UPDATE m_pengguna
+				SET active = TRUE,
+					activated_at = CASE WHEN NOT active THEN NOW() ELSE activated_at END,
+					active_until = CASE WHEN active_until <= NOW() THEN NULL ELSE active_until END,
+					updated_at = NOW()
+				WHERE id = ANY($1)
+			`
+		case "deactivate":
+			query = "UPDATE m_pengguna SET active = FALSE, updated_at = NOW() WHERE id = ANY($1)"
+		case "delete":
+			query = "UPDATE m_pengguna SET deleted_at = NOW(), updated_at = NOW() WHERE id = ANY($1)"
+		default:
+			return nil, spanError(span, fmt.Errorf("unknown bulk action %q", action))
+		}
+		if _, err := tx.ExecContext(ctx, query, pq.Array(targets)); err != nil {
+			return nil, spanError(span, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, spanError(span, err)
+	}
+
+	changed := make(map[int64]bool, len(targets))
+	for _, id := range targets {
+		changed[id] = true
+	}
+	if filter != nil {
+		ids = found
+	}
+
+	results := make([]BulkMasterPenggunaResult, 0, len(ids))
+	for _, id := range ids {
+		status := "not_found"
+		if _, ok := current[id]; ok {
+			status = "unchanged"
+			if changed[id] {
+				status = "updated"
+			}
+		}
+		results = append(results, BulkMasterPenggunaResult{ID: id, Status: status})
+	}
+	return results, nil
+}
+
+func (r *MasterPenggunaRepository) Update(ctx context.Context, id int64, idPengguna *int64, telegram *string, jenis *string, active *bool, activeUntil *time.Time, clearActiveUntil bool) error {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Update")
+	defer span.End()
+
 	setClauses := make([]string, 0, 4)
 	args := make([]any, 0, 6)
 	argPos := 1
@@ -220,47 +768,192 @@ func (r *MasterPenggunaRepository) Update(ctx context.Context, id int64, idPengg
 		argPos++
 	}
 	if active != nil {
+		setClauses = append(setClauses, fmt.Sprintf("activated_at = CASE WHEN $%d AND NOT active THEN NOW() ELSE activated_at END", argPos))
 		setClauses = append(setClauses, fmt.Sprintf("active = $%d", argPos))
 		args = append(args, *active)
 		argPos++
 	}
+	if activeUntil != nil {
+		setClauses = append(setClauses, fmt.Sprintf("active_until = $%d", argPos))
+		args = append(args, *activeUntil)
+		argPos++
+	} else if clearActiveUntil {
+		setClauses = append(setClauses, "active_until = NULL")
+	} else if active != nil && *active {
+		setClauses = append(setClauses, "active_until = CASE WHEN active_until <= NOW() THEN NULL ELSE active_until END")
+	}
 
 	setClauses = append(setClauses, "updated_at = NOW()")
 
 	args = append(args, id)
 	query := fmt.Sprintf("UPDATE m_pengguna SET %s WHERE id = $%d", strings.Join(setClauses, ", "), argPos)
 
-	_, err := r.db.ExecContext(ctx, query, args...)
-	return err
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return spanError(span, conflictError(err))
+	}
+	if idPengguna == nil && telegram == nil {
+		return nil
+	}
+	return spanError(span, refreshSearch(ctx, r.db, []int64{id}))
 }
 
 func (r *MasterPenggunaRepository) SoftDelete(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.SoftDelete")
+	defer span.End()
+
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE m_pengguna
-		SET deleted_at = NOW(), updated_at = NOW(), active = FALSE
-		WHERE id = $1
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
 	`, id)
-	return err
+	return spanError(span, err)
 }
 
-func (r *MasterPenggunaRepository) CountByActive(ctx context.Context) (int, int, error) {
-	var activeCount int
-	if err := r.db.QueryRowContext(ctx, `
-		SELECT COUNT(*)
-		FROM m_pengguna
-		WHERE deleted_at IS NULL AND active = TRUE
-	`).Scan(&activeCount); err != nil {
-		return 0, 0, err
+func (r *MasterPenggunaRepository) Restore(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.Restore")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE m_pengguna
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return spanError(span, conflictError(err))
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return spanError(span, err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *MasterPenggunaRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.PurgeDeleted")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM t_pengguna_detail
+		WHERE pengguna_id IN (
+			SELECT id FROM m_pengguna WHERE deleted_at < $1
+		)
+	`, before); err != nil {
+		return 0, spanError(span, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM m_pengguna
+		WHERE deleted_at < $1
+	`, before)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, spanError(span, err)
 	}
 
-	var inactiveCount int
+	return purged, spanError(span, tx.Commit())
+}
+
+func (r *MasterPenggunaRepository) ExpireDue(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.ExpireDue")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE m_pengguna
+		SET active = FALSE, updated_at = NOW()
+		WHERE active AND active_until <= NOW() AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	expired, err := res.RowsAffected()
+	return expired, spanError(span, err)
+}
+
+func (r *MasterPenggunaRepository) CountByActive(ctx context.Context) (int, int, error) {
+	ctx, span := startSpan(ctx, "MasterPenggunaRepository.CountByActive")
+	defer span.End()
+
+	var activeCount, inactiveCount int
 	if err := r.db.QueryRowContext(ctx, `
-		SELECT COUNT(*)
+		SELECT
+			COUNT(*) FILTER (WHERE active),
+			COUNT(*) FILTER (WHERE NOT active)
 		FROM m_pengguna
-		WHERE deleted_at IS NULL AND active = FALSE
-	`).Scan(&inactiveCount); err != nil {
-		return 0, 0, err
+		WHERE deleted_at IS NULL
+	`).Scan(&activeCount, &inactiveCount); err != nil {
+		return 0, 0, spanError(span, err)
 	}
 
 	return activeCount, inactiveCount, nil
 }
+
+func listConditions(search string, jenis string, deleted bool) ([]string, []any) {
+	deletedCondition := "deleted_at IS NULL"
+	if deleted {
+		deletedCondition = "deleted_at IS NOT NULL"
+	}
+	conditions := []string{
+		deletedCondition,
+		"($1 = '' OR search_vector @@ to_tsquery('simple', $2) OR search_text ILIKE '%' || $1 || '%')",
+	}
+	args := []any{search, prefixQuery(search)}
+
+	if jenis != "" {
+		conditions = append(conditions, fmt.Sprintf("jenis = $%d", len(args)+1))
+		args = append(args, jenis)
+	}
+
+	return conditions, args
+}
+
+func masterPenggunaSortValue(item model.MasterPengguna, balance string, field string) any {
+	switch field {
+	case "id_pengguna":
+		return item.IDPengguna
+	case "telegram":
+		if item.Telegram == nil {
+			return ""
+		}
+		return *item.Telegram
+	case "jenis":
+		return item.Jenis
+	case "active":
+		return item.Active
+	case "created_at":
+		return item.CreatedAt
+	case "balance":
+		return balance
+	case "last_login":
+		if item.LastLoginAt == nil {
+			return time.Unix(0, 0)
+		}
+		return *item.LastLoginAt
+	case "active_until":
+		if item.ActiveUntil == nil {
+			return "infinity"
+		}
+		return *item.ActiveUntil
+	default:
+		return item.ID
+	}
+}
+
+func conflictError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrIDPenggunaConflict
+	}
+	return err
+}
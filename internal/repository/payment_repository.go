@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+)
+
+var PaymentQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":          "id",
+		"received_at": "received_at",
+		"amount":      "amount",
+	},
+	Filters: map[string]queryspec.Filter{
+		"provider":    {Kind: queryspec.Equal, Column: "provider"},
+		"id_pengguna": {Kind: queryspec.Equal, Column: "id_pengguna::text"},
+		"pengguna_id": {Kind: queryspec.Equal, Column: "pengguna_id::text"},
+		"received_at": {Kind: queryspec.TimeRange, Column: "received_at"},
+	},
+	Default: []queryspec.Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
+const paymentColumns = `id, provider, provider_payment_id, COALESCE(reference, ''), pengguna_id, id_pengguna, amount, currency,
+	period_days, active_until, paid_at, payload, received_at`
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+func (r *PaymentRepository) Apply(ctx context.Context, p model.Payment) (model.Payment, bool, error) {
+	ctx, span := startSpan(ctx, "PaymentRepository.Apply")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Payment{}, false, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	var paymentID int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO t_payment (provider, provider_payment_id, reference, id_pengguna, amount, currency, period_days, paid_at, payload)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9::jsonb)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, p.Provider, p.ProviderPaymentID, p.Reference, p.IDPengguna, p.Amount, p.Currency, p.PeriodDays, p.PaidAt, string(p.Payload)).Scan(&paymentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		existing, err := r.get(ctx, p.Provider, p.ProviderPaymentID, p.Reference)
+		return existing, false, spanError(span, err)
+	}
+	if err != nil {
+		return model.Payment{}, false, spanError(span, err)
+	}
+
+	var penggunaID int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM m_pengguna
+		WHERE id_pengguna = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, p.IDPengguna).Scan(&penggunaID)
+	registered := errors.Is(err, sql.ErrNoRows)
+	if registered {
+		err = tx.QueryRowContext(ctx, `
+			UPDATE m_pengguna
+			SET deleted_at = NULL, active = FALSE, active_until = NULL, updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM m_pengguna
+				WHERE id_pengguna = $1 AND deleted_at IS NOT NULL
+				ORDER BY deleted_at DESC
+				LIMIT 1
+			)
+			RETURNING id
+		`, p.IDPengguna).Scan(&penggunaID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO m_pengguna (id_pengguna, jenis, active)
+			VALUES ($1, 'stockity', FALSE)
+			RETURNING id
+		`, p.IDPengguna).Scan(&penggunaID)
+	}
+	if err != nil {
+		return model.Payment{}, false, spanError(span, conflictError(err))
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE m_pengguna
+		SET active = TRUE,
+			activated_at = CASE WHEN active THEN activated_at ELSE NOW() END,
+			active_until = CASE
+				WHEN active AND active_until IS NULL THEN NULL
+				ELSE GREATEST(COALESCE(active_until, NOW()), NOW()) + make_interval(days => $2)
+			END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, penggunaID, p.PeriodDays); err != nil {
+		return model.Payment{}, false, spanError(span, err)
+	}
+
+	applied, err := scanPayment(tx.QueryRowContext(ctx, `
+		UPDATE t_payment
+		SET pengguna_id = $2, active_until = (SELECT active_until FROM m_pengguna WHERE id = $2)
+		WHERE id = $1
+		RETURNING `+paymentColumns,
+		paymentID, penggunaID))
+	if err != nil {
+		return model.Payment{}, false, spanError(span, err)
+	}
+
+	if registered {
+		if err := refreshSearch(ctx, tx, []int64{penggunaID}); err != nil {
+			return model.Payment{}, false, spanError(span, err)
+		}
+	}
+	return applied, true, spanError(span, tx.Commit())
+}
+
+func (r *PaymentRepository) List(ctx context.Context, q queryspec.Query) ([]model.Payment, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "PaymentRepository.List")
+	defer span.End()
+
+	conditions := []string{"TRUE"}
+	args := []any{}
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
+
+	limit, offset := q.Window()
+	query := fmt.Sprintf(`
+		SELECT %s, amount::text, %s
+		FROM t_payment
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, paymentColumns, q.TotalColumn(), strings.Join(conditions, " AND "), q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	var total int
+	payments := make([]model.Payment, 0)
+	amounts := make(map[int64]string)
+	for rows.Next() {
+		var amount string
+		payment, err := scanPayment(rows, &amount, &total)
+		if err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		amounts[payment.ID] = amount
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(payments)) {
+		if total, err = countTotal(ctx, r.db, "t_payment", strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	payments, page, err := queryspec.Finish(q, payments, total, func(payment model.Payment, field string) any {
+		return paymentSortValue(payment, amounts[payment.ID], field)
+	})
+	return payments, page, spanError(span, err)
+}
+
+func (r *PaymentRepository) get(ctx context.Context, provider, providerPaymentID, reference string) (model.Payment, error) {
+	return scanPayment(r.db.QueryRowContext(ctx, `
+		SELECT `+paymentColumns+`
+		FROM t_payment
+		WHERE provider = $1 AND (provider_payment_id = $2 OR reference = NULLIF($3, ''))
+		ORDER BY id
+		LIMIT 1
+	`, provider, providerPaymentID, reference))
+}
+
+func scanPayment(row rowScanner, extra ...any) (model.Payment, error) {
+	var payment model.Payment
+	var payload []byte
+	var penggunaID sql.NullInt64
+	var activeUntil sql.NullTime
+	dest := append([]any{
+		&payment.ID,
+		&payment.Provider,
+		&payment.ProviderPaymentID,
+		&payment.Reference,
+		&penggunaID,
+		&payment.IDPengguna,
+		&payment.Amount,
+		&payment.Currency,
+		&payment.PeriodDays,
+		&activeUntil,
+		&payment.PaidAt,
+		&payload,
+		&payment.ReceivedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return model.Payment{}, err
+	}
+	payment.Payload = payload
+	if penggunaID.Valid {
+		val := penggunaID.Int64
+		payment.PenggunaID = &val
+	}
+	if activeUntil.Valid {
+		val := activeUntil.Time
+		payment.ActiveUntil = &val
+	}
+	return payment, nil
+}
+
+func paymentSortValue(payment model.Payment, amount string, field string) any {
+	switch field {
+	case "received_at":
+		return payment.ReceivedAt
+	case "amount":
+		return amount
+	default:
+		return payment.ID
+	}
+}
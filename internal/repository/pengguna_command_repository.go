@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+)
+
+var PenggunaCommandQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	Filters: map[string]queryspec.Filter{
+		"status":     {Kind: queryspec.Equal, Column: "status"},
+		"command":    {Kind: queryspec.Equal, Column: "command"},
+		"created_at": {Kind: queryspec.TimeRange, Column: "created_at"},
+	},
+	Default: []queryspec.Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
+const commandColumns = `id, pengguna_id, command, payload, status, COALESCE(device_id, ''), attempts, COALESCE(result, ''),
+	COALESCE(created_by, ''), created_at, expires_at, delivered_at, acked_at`
+
+const commandClaimLimit = 20
+
+type PenggunaCommandRepository struct {
+	db *sql.DB
+}
+
+func NewPenggunaCommandRepository(db *sql.DB) *PenggunaCommandRepository {
+	return &PenggunaCommandRepository{db: db}
+}
+
+func (r *PenggunaCommandRepository) Create(ctx context.Context, cmd model.PenggunaCommand) (model.PenggunaCommand, error) {
+	ctx, span := startSpan(ctx, "PenggunaCommandRepository.Create")
+	defer span.End()
+
+	var payload *string
+	if len(cmd.Payload) > 0 {
+		val := string(cmd.Payload)
+		payload = &val
+	}
+
+	row := r.db.QueryRowContext(ctx, `
+		INSERT INTO t_pengguna_command (pengguna_id, command, payload, created_by, expires_at)
+		VALUES ($1, $2, $3::jsonb, $4, $5)
+		RETURNING `+commandColumns,
+		cmd.PenggunaID, cmd.Command, payload, cmd.CreatedBy, cmd.ExpiresAt)
+	created, err := scanCommand(row)
+	return created, spanError(span, missingPengguna(err))
+}
+
+func (r *PenggunaCommandRepository) List(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaCommand, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "PenggunaCommandRepository.List")
+	defer span.End()
+
+	conditions := []string{"pengguna_id = $1"}
+	args := []any{penggunaID}
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
+
+	limit, offset := q.Window()
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM t_pengguna_command
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, commandColumns, q.TotalColumn(), strings.Join(conditions, " AND "), q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	var total int
+	commands := make([]model.PenggunaCommand, 0)
+	for rows.Next() {
+		cmd, err := scanCommand(rows, &total)
+		if err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		commands = append(commands, cmd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(commands)) {
+		if total, err = countTotal(ctx, r.db, "t_pengguna_command", strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	commands, page, err := queryspec.Finish(q, commands, total, penggunaCommandSortValue)
+	return commands, page, spanError(span, err)
+}
+
+func (r *PenggunaCommandRepository) Claim(ctx context.Context, penggunaID int64, deviceID string, redeliverBefore time.Time) ([]model.PenggunaCommand, error) {
+	ctx, span := startSpan(ctx, "PenggunaCommandRepository.Claim")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE t_pengguna_command
+		SET status = 'delivered', delivered_at = NOW(), device_id = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM t_pengguna_command
+			WHERE pengguna_id = $1
+				AND expires_at > NOW()
+				AND (status = 'pending' OR (status = 'delivered' AND delivered_at < $3))
+			ORDER BY id
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+commandColumns,
+		penggunaID, deviceID, redeliverBefore, commandClaimLimit)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	commands := make([]model.PenggunaCommand, 0)
+	for rows.Next() {
+		cmd, err := scanCommand(rows)
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+		commands = append(commands, cmd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, spanError(span, err)
+	}
+
+	sort.Slice(commands, func(i, j int) bool { return commands[i].ID < commands[j].ID })
+	return commands, nil
+}
+
+func (r *PenggunaCommandRepository) Ack(ctx context.Context, penggunaID, id int64, status, result string) error {
+	ctx, span := startSpan(ctx, "PenggunaCommandRepository.Ack")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE t_pengguna_command
+		SET status = $3, result = NULLIF($4, ''), acked_at = NOW()
+		WHERE id = $1 AND pengguna_id = $2 AND status IN ('pending', 'delivered')
+	`, id, penggunaID, status, result)
+	return spanError(span, affectedOrNoRows(res, err))
+}
+
+func (r *PenggunaCommandRepository) Cancel(ctx context.Context, penggunaID, id int64) error {
+	ctx, span := startSpan(ctx, "PenggunaCommandRepository.Cancel")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE t_pengguna_command
+		SET status = 'cancelled'
+		WHERE id = $1 AND pengguna_id = $2 AND status IN ('pending', 'delivered')
+	`, id, penggunaID)
+	return spanError(span, affectedOrNoRows(res, err))
+}
+
+func (r *PenggunaCommandRepository) ExpireDue(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "PenggunaCommandRepository.ExpireDue")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE t_pengguna_command
+		SET status = 'expired'
+		WHERE status IN ('pending', 'delivered') AND expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	expired, err := res.RowsAffected()
+	return expired, spanError(span, err)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCommand(row rowScanner, extra ...any) (model.PenggunaCommand, error) {
+	var cmd model.PenggunaCommand
+	var payload []byte
+	var deliveredAt, ackedAt sql.NullTime
+	dest := append([]any{
+		&cmd.ID,
+		&cmd.PenggunaID,
+		&cmd.Command,
+		&payload,
+		&cmd.Status,
+		&cmd.DeviceID,
+		&cmd.Attempts,
+		&cmd.Result,
+		&cmd.CreatedBy,
+		&cmd.CreatedAt,
+		&cmd.ExpiresAt,
+		&deliveredAt,
+		&ackedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return model.PenggunaCommand{}, err
+	}
+	cmd.Payload = payload
+	if deliveredAt.Valid {
+		val := deliveredAt.Time
+		cmd.DeliveredAt = &val
+	}
+	if ackedAt.Valid {
+		val := ackedAt.Time
+		cmd.AckedAt = &val
+	}
+	return cmd, nil
+}
+
+func missingPengguna(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+func countTotal(ctx context.Context, db *sql.DB, from, where string, args []any) (int, error) {
+	var total int
+	err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", from, where), args...).Scan(&total)
+	return total, err
+}
+
+func affectedOrNoRows(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func penggunaCommandSortValue(cmd model.PenggunaCommand, field string) any {
+	if field == "created_at" {
+		return cmd.CreatedAt
+	}
+	return cmd.ID
+}
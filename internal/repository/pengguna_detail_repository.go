@@ -17,6 +17,9 @@ func NewPenggunaDetailRepository(db *sql.DB) *PenggunaDetailRepository {
 }
 
 func (r *PenggunaDetailRepository) GetByPenggunaID(ctx context.Context, penggunaID int64) (model.PenggunaDetail, error) {
+	ctx, span := startSpan(ctx, "PenggunaDetailRepository.GetByPenggunaID")
+	defer span.End()
+
 	var detail model.PenggunaDetail
 	var avatar sql.NullString
 	var registeredAt sql.NullTime
@@ -77,7 +80,7 @@ func (r *PenggunaDetailRepository) GetByPenggunaID(ctx context.Context, pengguna
 		&detail.RegistrationCountryISO,
 	)
 	if err != nil {
-		return model.PenggunaDetail{}, err
+		return model.PenggunaDetail{}, spanError(span, err)
 	}
 
 	if avatar.Valid {
@@ -102,7 +105,16 @@ func (r *PenggunaDetailRepository) GetByPenggunaID(ctx context.Context, pengguna
 }
 
 func (r *PenggunaDetailRepository) Upsert(ctx context.Context, detail model.PenggunaDetail) error {
-	_, err := r.db.ExecContext(ctx, `
+	ctx, span := startSpan(ctx, "PenggunaDetailRepository.Upsert")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
 		INSERT INTO t_pengguna_detail (
 			id, pengguna_id, avatar, first_name, last_name, nickname,
 			balance, balance_version, bonus, gender, email, email_verified,
@@ -201,7 +213,23 @@ func (r *PenggunaDetailRepository) Upsert(ctx context.Context, detail model.Peng
 		detail.PreserveName,
 		detail.RegistrationCountryISO,
 	)
-	return err
+	if err != nil {
+		return spanError(span, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO t_pengguna_balance_history (pengguna_id, balance, balance_version, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (pengguna_id, balance_version) DO NOTHING
+	`, detail.PenggunaID, detail.Balance, detail.BalanceVersion, detail.Currency)
+	if err != nil {
+		return spanError(span, err)
+	}
+
+	if err := refreshSearch(ctx, tx, []int64{detail.PenggunaID}); err != nil {
+		return spanError(span, err)
+	}
+	return spanError(span, tx.Commit())
 }
 
 func nullableString(val *string) sql.NullString {
@@ -211,6 +239,13 @@ func nullableString(val *string) sql.NullString {
 	return sql.NullString{String: *val, Valid: true}
 }
 
+func nullableBool(val *bool) sql.NullBool {
+	if val == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *val, Valid: true}
+}
+
 func nullableJSON(val []byte) sql.NullString {
 	if len(val) == 0 {
 		return sql.NullString{}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"database/sql"
+
+	"koalbot_api/internal/model"
+)
+
+const detailSummaryColumns = `detail_id, detail_first_name, detail_last_name, detail_nickname, detail_email,
+	detail_balance, detail_currency, detail_country, detail_blocked`
+
+const detailSummaryJoin = `LEFT JOIN LATERAL (
+		SELECT
+			d.id AS detail_id,
+			d.first_name AS detail_first_name,
+			d.last_name AS detail_last_name,
+			d.nickname AS detail_nickname,
+			d.email AS detail_email,
+			d.balance AS detail_balance,
+			d.currency AS detail_currency,
+			d.country AS detail_country,
+			d.blocked AS detail_blocked
+		FROM t_pengguna_detail d
+		WHERE d.pengguna_id = m_pengguna.id
+		ORDER BY d.id DESC
+		LIMIT 1
+	) detail ON TRUE`
+
+type detailSummaryScan struct {
+	id        sql.NullInt64
+	firstName sql.NullString
+	lastName  sql.NullString
+	nickname  sql.NullString
+	email     sql.NullString
+	balance   sql.NullFloat64
+	currency  sql.NullString
+	country   sql.NullString
+	blocked   sql.NullBool
+}
+
+func (s *detailSummaryScan) dest() []any {
+	return []any{
+		&s.id,
+		&s.firstName,
+		&s.lastName,
+		&s.nickname,
+		&s.email,
+		&s.balance,
+		&s.currency,
+		&s.country,
+		&s.blocked,
+	}
+}
+
+func (s *detailSummaryScan) summary() *model.PenggunaDetailSummary {
+	if !s.id.Valid {
+		return nil
+	}
+	return &model.PenggunaDetailSummary{
+		ID:        s.id.Int64,
+		FirstName: s.firstName.String,
+		LastName:  s.lastName.String,
+		Nickname:  s.nickname.String,
+		Email:     s.email.String,
+		Balance:   s.balance.Float64,
+		Currency:  s.currency.String,
+		Country:   s.country.String,
+		Blocked:   s.blocked.Bool,
+	}
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"koalbot_api/internal/model"
+)
+
+var ErrDeviceLimit = errors.New("device_limit")
+
+type PenggunaDeviceRepository struct {
+	db *sql.DB
+}
+
+func NewPenggunaDeviceRepository(db *sql.DB) *PenggunaDeviceRepository {
+	return &PenggunaDeviceRepository{db: db}
+}
+
+func (r *PenggunaDeviceRepository) Bind(ctx context.Context, device model.PenggunaDevice, max int, evict bool, idle time.Duration) ([]model.PenggunaDevice, error) {
+	ctx, span := startSpan(ctx, "PenggunaDeviceRepository.Bind")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM m_pengguna WHERE id = $1 FOR UPDATE`, device.PenggunaID); err != nil {
+		return nil, spanError(span, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE t_pengguna_device
+		SET device_type = $3, last_ip = $4, last_seen_at = NOW()
+		WHERE pengguna_id = $1 AND device_id = $2
+	`, device.PenggunaID, device.DeviceID, device.DeviceType, device.LastIP)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	if affected > 0 {
+		return nil, spanError(span, tx.Commit())
+	}
+
+	var evicted []model.PenggunaDevice
+	if max > 0 && idle > 0 {
+		evicted, err = evictIdle(ctx, tx, device.PenggunaID, time.Now().Add(-idle))
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+	}
+	if max > 0 {
+		var bound int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM t_pengguna_device WHERE pengguna_id = $1
+		`, device.PenggunaID).Scan(&bound); err != nil {
+			return nil, spanError(span, err)
+		}
+		if bound >= max {
+			if !evict {
+				return nil, ErrDeviceLimit
+			}
+			oldest, err := evictOldest(ctx, tx, device.PenggunaID, bound-max+1)
+			if err != nil {
+				return nil, spanError(span, err)
+			}
+			evicted = append(evicted, oldest...)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO t_pengguna_device (pengguna_id, device_id, device_type, last_ip)
+		VALUES ($1, $2, $3, $4)
+	`, device.PenggunaID, device.DeviceID, device.DeviceType, device.LastIP); err != nil {
+		return nil, spanError(span, err)
+	}
+
+	return evicted, spanError(span, tx.Commit())
+}
+
+func (r *PenggunaDeviceRepository) List(ctx context.Context, penggunaID int64) ([]model.PenggunaDevice, error) {
+	ctx, span := startSpan(ctx, "PenggunaDeviceRepository.List")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, pengguna_id, device_id, device_type, COALESCE(last_ip, ''), bound_at, last_seen_at
+		FROM t_pengguna_device
+		WHERE pengguna_id = $1
+		ORDER BY last_seen_at DESC, id DESC
+	`, penggunaID)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	devices, err := scanDevices(rows)
+	return devices, spanError(span, err)
+}
+
+func (r *PenggunaDeviceRepository) IsBound(ctx context.Context, penggunaID int64, deviceID string) (bool, error) {
+	ctx, span := startSpan(ctx, "PenggunaDeviceRepository.IsBound")
+	defer span.End()
+
+	var bound bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM t_pengguna_device WHERE pengguna_id = $1 AND device_id = $2
+		)
+	`, penggunaID, deviceID).Scan(&bound)
+	return bound, spanError(span, err)
+}
+
+func (r *PenggunaDeviceRepository) Unbind(ctx context.Context, penggunaID int64, deviceID string) error {
+	ctx, span := startSpan(ctx, "PenggunaDeviceRepository.Unbind")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM t_pengguna_device
+		WHERE pengguna_id = $1 AND device_id = $2
+	`, penggunaID, deviceID)
+	if err != nil {
+		return spanError(span, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return spanError(span, err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func evictIdle(ctx context.Context, tx *sql.Tx, penggunaID int64, before time.Time) ([]model.PenggunaDevice, error) {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM t_pengguna_device
+		WHERE pengguna_id = $1 AND last_seen_at < $2
+		RETURNING id, pengguna_id, device_id, device_type, COALESCE(last_ip, ''), bound_at, last_seen_at
+	`, penggunaID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanDevices(rows)
+}
+
+func evictOldest(ctx context.Context, tx *sql.Tx, penggunaID int64, count int) ([]model.PenggunaDevice, error) {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM t_pengguna_device
+		WHERE id IN (
+			SELECT id FROM t_pengguna_device
+			WHERE pengguna_id = $1
+			ORDER BY last_seen_at ASC, id ASC
+			LIMIT $2
+		)
+		RETURNING id, pengguna_id, device_id, device_type, COALESCE(last_ip, ''), bound_at, last_seen_at
+	`, penggunaID, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanDevices(rows)
+}
+
+func scanDevices(rows *sql.Rows) ([]model.PenggunaDevice, error) {
+	devices := make([]model.PenggunaDevice, 0)
+	for rows.Next() {
+		var device model.PenggunaDevice
+		if err := rows.Scan(
+			&device.ID,
+			&device.PenggunaID,
+			&device.DeviceID,
+			&device.DeviceType,
+			&device.LastIP,
+			&device.BoundAt,
+			&device.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+)
+
+var PenggunaLoginQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	Filters: map[string]queryspec.Filter{
+		"outcome":    {Kind: queryspec.Equal, Column: "outcome"},
+		"created_at": {Kind: queryspec.TimeRange, Column: "created_at"},
+	},
+	Default: []queryspec.Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
+var PenggunaAlertQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":         "a.id",
+		"created_at": "a.created_at",
+	},
+	Filters: map[string]queryspec.Filter{
+		"kind":       {Kind: queryspec.Equal, Column: "a.kind"},
+		"created_at": {Kind: queryspec.TimeRange, Column: "a.created_at"},
+	},
+	Default: []queryspec.Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
+type PenggunaLoginRepository struct {
+	db *sql.DB
+}
+
+func NewPenggunaLoginRepository(db *sql.DB) *PenggunaLoginRepository {
+	return &PenggunaLoginRepository{db: db}
+}
+
+func (r *PenggunaLoginRepository) Record(ctx context.Context, login model.PenggunaLogin) error {
+	ctx, span := startSpan(ctx, "PenggunaLoginRepository.Record")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	if login.PenggunaID == nil && login.Email != "" {
+		var penggunaID, idPengguna int64
+		err := tx.QueryRowContext(ctx, `
+			SELECT m.id, m.id_pengguna
+			FROM t_pengguna_detail d
+			JOIN m_pengguna m ON m.id = d.pengguna_id
+			WHERE lower(d.email) = $1 AND m.deleted_at IS NULL
+			ORDER BY d.id DESC
+			LIMIT 1
+		`, login.Email).Scan(&penggunaID, &idPengguna)
+		switch {
+		case err == nil:
+			login.PenggunaID = &penggunaID
+			login.IDPengguna = &idPengguna
+		case !errors.Is(err, sql.ErrNoRows):
+			return spanError(span, err)
+		}
+	}
+
+	var createdAt time.Time
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO t_pengguna_login (pengguna_id, id_pengguna, email, outcome, device_id, device_type, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`, login.PenggunaID, login.IDPengguna, login.Email, login.Outcome, login.DeviceID, login.DeviceType, login.IP).Scan(&createdAt); err != nil {
+		return spanError(span, err)
+	}
+
+	if login.Outcome == model.LoginSuccess && login.PenggunaID != nil {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE m_pengguna SET last_login_at = $2 WHERE id = $1
+		`, *login.PenggunaID, createdAt); err != nil {
+			return spanError(span, err)
+		}
+	}
+
+	return spanError(span, tx.Commit())
+}
+
+func (r *PenggunaLoginRepository) CountDevices(ctx context.Context, penggunaID int64, since time.Time) (int, error) {
+	ctx, span := startSpan(ctx, "PenggunaLoginRepository.CountDevices")
+	defer span.End()
+
+	var devices int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT device_id)
+		FROM t_pengguna_login
+		WHERE pengguna_id = $1 AND outcome = $2 AND created_at >= $3
+	`, penggunaID, model.LoginSuccess, since).Scan(&devices)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	return devices, nil
+}
+
+func (r *PenggunaLoginRepository) List(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaLogin, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "PenggunaLoginRepository.List")
+	defer span.End()
+
+	conditions := []string{"pengguna_id = $1"}
+	args := []any{penggunaID}
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
+
+	limit, offset := q.Window()
+	query := fmt.Sprintf(`
+		SELECT id, pengguna_id, id_pengguna, COALESCE(email, ''), outcome, device_id, device_type, COALESCE(ip, ''), created_at, %s
+		FROM t_pengguna_login
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, q.TotalColumn(), strings.Join(conditions, " AND "), q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	var total int
+	logins := make([]model.PenggunaLogin, 0)
+	for rows.Next() {
+		var login model.PenggunaLogin
+		var penggunaID, idPengguna sql.NullInt64
+		if err := rows.Scan(
+			&login.ID,
+			&penggunaID,
+			&idPengguna,
+			&login.Email,
+			&login.Outcome,
+			&login.DeviceID,
+			&login.DeviceType,
+			&login.IP,
+			&login.CreatedAt,
+			&total,
+		); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		if penggunaID.Valid {
+			val := penggunaID.Int64
+			login.PenggunaID = &val
+		}
+		if idPengguna.Valid {
+			val := idPengguna.Int64
+			login.IDPengguna = &val
+		}
+		logins = append(logins, login)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(logins)) {
+		if total, err = countTotal(ctx, r.db, "t_pengguna_login", strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	logins, page, err := queryspec.Finish(q, logins, total, penggunaLoginSortValue)
+	return logins, page, spanError(span, err)
+}
+
+func (r *PenggunaLoginRepository) CreateAlertOnce(ctx context.Context, penggunaID int64, kind string, detail any, since time.Time) (bool, error) {
+	ctx, span := startSpan(ctx, "PenggunaLoginRepository.CreateAlertOnce")
+	defer span.End()
+
+	payload, err := json.Marshal(detail)
+	if err != nil {
+		return false, spanError(span, err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO t_pengguna_alert (pengguna_id, kind, detail)
+		SELECT $1::bigint, $2::varchar, $3::jsonb
+		WHERE NOT EXISTS (
+			SELECT 1 FROM t_pengguna_alert
+			WHERE pengguna_id = $1 AND kind = $2 AND created_at >= $4
+		)
+	`, penggunaID, kind, string(payload), since)
+	if err != nil {
+		return false, spanError(span, err)
+	}
+	created, err := res.RowsAffected()
+	if err != nil {
+		return false, spanError(span, err)
+	}
+	return created > 0, nil
+}
+
+func (r *PenggunaLoginRepository) ListAlerts(ctx context.Context, q queryspec.Query) ([]model.PenggunaAlert, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "PenggunaLoginRepository.ListAlerts")
+	defer span.End()
+
+	conditions, args := q.Conditions(1)
+	where := "TRUE"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+	argPos := len(args) + 1
+
+	limit, offset := q.Window()
+	query := fmt.Sprintf(`
+		SELECT a.id, a.pengguna_id, m.id_pengguna, a.kind, a.detail, a.created_at, %s
+		FROM t_pengguna_alert a
+		JOIN m_pengguna m ON m.id = a.pengguna_id
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, q.TotalColumn(), where, q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	var total int
+	alerts := make([]model.PenggunaAlert, 0)
+	for rows.Next() {
+		var alert model.PenggunaAlert
+		var detail []byte
+		if err := rows.Scan(
+			&alert.ID,
+			&alert.PenggunaID,
+			&alert.IDPengguna,
+			&alert.Kind,
+			&detail,
+			&alert.CreatedAt,
+			&total,
+		); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		alert.Detail = detail
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(alerts)) {
+		if total, err = countTotal(ctx, r.db, "t_pengguna_alert a JOIN m_pengguna m ON m.id = a.pengguna_id", where, args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	alerts, page, err := queryspec.Finish(q, alerts, total, penggunaAlertSortValue)
+	return alerts, page, spanError(span, err)
+}
+
+func penggunaLoginSortValue(login model.PenggunaLogin, field string) any {
+	if field == "created_at" {
+		return login.CreatedAt
+	}
+	return login.ID
+}
+
+func penggunaAlertSortValue(alert model.PenggunaAlert, field string) any {
+	if field == "created_at" {
+		return alert.CreatedAt
+	}
+	return alert.ID
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"koalbot_api/internal/model"
+)
+
+type PenggunaPresenceRepository struct {
+	db *sql.DB
+}
+
+func NewPenggunaPresenceRepository(db *sql.DB) *PenggunaPresenceRepository {
+	return &PenggunaPresenceRepository{db: db}
+}
+
+func (r *PenggunaPresenceRepository) SavePresence(ctx context.Context, entries []model.PenggunaPresence) error {
+	ctx, span := startSpan(ctx, "PenggunaPresenceRepository.SavePresence")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	upsert, err := tx.PrepareContext(ctx, `
+		INSERT INTO t_pengguna_presence (pengguna_id, device_id, device_type, client_version, running, state, ip, last_seen_at)
+		SELECT $1::bigint, $2::text, $3::text, $4::text, $5::boolean, $6::text, $7::text, $8::timestamptz
+		WHERE EXISTS (SELECT 1 FROM m_pengguna WHERE id = $1)
+		ON CONFLICT (pengguna_id) DO UPDATE SET
+			device_id = EXCLUDED.device_id,
+			device_type = EXCLUDED.device_type,
+			client_version = EXCLUDED.client_version,
+			running = EXCLUDED.running,
+			state = EXCLUDED.state,
+			ip = EXCLUDED.ip,
+			last_seen_at = GREATEST(t_pengguna_presence.last_seen_at, EXCLUDED.last_seen_at)
+	`)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer upsert.Close()
+
+	touch, err := tx.PrepareContext(ctx, `
+		UPDATE t_pengguna_device
+		SET last_seen_at = GREATEST(last_seen_at, $3)
+		WHERE pengguna_id = $1 AND device_id = $2
+	`)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer touch.Close()
+
+	for _, p := range entries {
+		if _, err := upsert.ExecContext(ctx, p.PenggunaID, p.DeviceID, p.DeviceType, p.ClientVersion, p.Running, p.State, p.IP, p.LastSeen); err != nil {
+			return spanError(span, err)
+		}
+		if _, err := touch.ExecContext(ctx, p.PenggunaID, p.DeviceID, p.LastSeen); err != nil {
+			return spanError(span, err)
+		}
+	}
+
+	return spanError(span, tx.Commit())
+}
+
+func (r *PenggunaPresenceRepository) Get(ctx context.Context, penggunaID int64) (model.PenggunaPresence, error) {
+	ctx, span := startSpan(ctx, "PenggunaPresenceRepository.Get")
+	defer span.End()
+
+	var p model.PenggunaPresence
+	err := r.db.QueryRowContext(ctx, `
+		SELECT p.pengguna_id, m.id_pengguna, p.device_id, COALESCE(p.device_type, ''), COALESCE(p.client_version, ''),
+			p.running, COALESCE(p.state, ''), COALESCE(p.ip, ''), p.last_seen_at
+		FROM t_pengguna_presence p
+		JOIN m_pengguna m ON m.id = p.pengguna_id
+		WHERE p.pengguna_id = $1
+	`, penggunaID).Scan(
+		&p.PenggunaID,
+		&p.IDPengguna,
+		&p.DeviceID,
+		&p.DeviceType,
+		&p.ClientVersion,
+		&p.Running,
+		&p.State,
+		&p.IP,
+		&p.LastSeen,
+	)
+	if err != nil {
+		return model.PenggunaPresence{}, spanError(span, err)
+	}
+	return p, nil
+}
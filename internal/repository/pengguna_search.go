@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+const searchRank = "(ts_rank(search_vector, to_tsquery('simple', $2)) + similarity(search_text, lower($1)))"
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func refreshSearch(ctx context.Context, db execer, ids []int64) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE m_pengguna m
+		SET search_text = s.text, search_vector = to_tsvector('simple', s.text)
+		FROM (
+			SELECT id, f_pengguna_search_text(id) AS text
+			FROM m_pengguna
+			WHERE id = ANY($1)
+		) s
+		WHERE m.id = s.id
+	`, pq.Array(ids))
+	return err
+}
+
+func prefixQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+	return strings.Join(terms, " & ")
+}
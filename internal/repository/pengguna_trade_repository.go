@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+)
+
+var PenggunaTradeQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":        "t.id",
+		"opened_at": "t.opened_at",
+		"amount":    "t.amount",
+		"profit":    "COALESCE(t.payout - t.amount, 0)",
+	},
+	Filters: map[string]queryspec.Filter{
+		"pengguna_id":  {Kind: queryspec.Equal, Column: "t.pengguna_id::text"},
+		"id_pengguna":  {Kind: queryspec.Equal, Column: "m.id_pengguna::text"},
+		"asset":        {Kind: queryspec.Equal, Column: "t.asset"},
+		"direction":    {Kind: queryspec.Equal, Column: "t.direction"},
+		"result":       {Kind: queryspec.Equal, Column: "t.result"},
+		"account_type": {Kind: queryspec.Equal, Column: "t.account_type"},
+		"opened_at":    {Kind: queryspec.TimeRange, Column: "t.opened_at"},
+		"closed":       {Kind: queryspec.Bool, Column: "(t.closed_at IS NOT NULL)"},
+	},
+	Default: []queryspec.Sort{{Field: "opened_at", Desc: true}},
+	Key:     "id",
+}
+
+const tradeColumns = `t.id, t.pengguna_id, m.id_pengguna, t.client_trade_id, COALESCE(t.device_id, ''), t.asset, t.direction,
+	t.amount, t.account_type, t.opened_at, t.closed_at, COALESCE(t.result, ''), t.payout, t.created_at, t.updated_at`
+
+const tradeSortKeyColumns = `t.amount::text, COALESCE(t.payout - t.amount, 0)::text`
+
+type tradeSortKeys struct {
+	amount string
+	profit string
+}
+
+type PenggunaTradeRepository struct {
+	db *sql.DB
+
+	mu         sync.Mutex
+	partitions map[string]bool
+}
+
+func NewPenggunaTradeRepository(db *sql.DB) *PenggunaTradeRepository {
+	return &PenggunaTradeRepository{db: db, partitions: make(map[string]bool)}
+}
+
+func (r *PenggunaTradeRepository) Ingest(ctx context.Context, trades []model.PenggunaTrade) ([]string, error) {
+	ctx, span := startSpan(ctx, "PenggunaTradeRepository.Ingest")
+	defer span.End()
+
+	for _, trade := range trades {
+		if err := r.ensurePartition(ctx, trade.OpenedAt); err != nil {
+			return nil, spanError(span, err)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	claim, err := tx.PrepareContext(ctx, `
+		INSERT INTO t_pengguna_trade_key (pengguna_id, client_trade_id, opened_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (pengguna_id, client_trade_id) DO NOTHING
+	`)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer claim.Close()
+
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO t_pengguna_trade (pengguna_id, client_trade_id, device_id, asset, direction, amount, account_type, opened_at, closed_at, result, payout)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11)
+	`)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer insert.Close()
+
+	settle, err := tx.PrepareContext(ctx, `
+		UPDATE t_pengguna_trade t
+		SET closed_at = $3, result = NULLIF($4, ''), payout = $5, updated_at = NOW()
+		FROM t_pengguna_trade_key k
+		WHERE k.pengguna_id = $1 AND k.client_trade_id = $2
+			AND t.pengguna_id = k.pengguna_id AND t.client_trade_id = k.client_trade_id AND t.opened_at = k.opened_at
+			AND t.closed_at IS NULL
+	`)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer settle.Close()
+
+	statuses := make([]string, len(trades))
+	for i, trade := range trades {
+		res, err := claim.ExecContext(ctx, trade.PenggunaID, trade.ClientTradeID, trade.OpenedAt)
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+		claimed, err := res.RowsAffected()
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+
+		if claimed > 0 {
+			if _, err := insert.ExecContext(ctx,
+				trade.PenggunaID,
+				trade.ClientTradeID,
+				trade.DeviceID,
+				trade.Asset,
+				trade.Direction,
+				trade.Amount,
+				trade.AccountType,
+				trade.OpenedAt,
+				trade.ClosedAt,
+				trade.Result,
+				trade.Payout,
+			); err != nil {
+				return nil, spanError(span, err)
+			}
+			statuses[i] = model.TradeCreated
+			continue
+		}
+
+		statuses[i] = model.TradeDuplicate
+		if trade.ClosedAt == nil {
+			continue
+		}
+		res, err = settle.ExecContext(ctx, trade.PenggunaID, trade.ClientTradeID, trade.ClosedAt, trade.Result, trade.Payout)
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+		if settled, err := res.RowsAffected(); err != nil {
+			return nil, spanError(span, err)
+		} else if settled > 0 {
+			statuses[i] = model.TradeUpdated
+		}
+	}
+
+	return statuses, spanError(span, tx.Commit())
+}
+
+func (r *PenggunaTradeRepository) List(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaTrade, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "PenggunaTradeRepository.List")
+	defer span.End()
+
+	conditions := []string{"TRUE"}
+	args := []any{}
+	if penggunaID > 0 {
+		conditions = append(conditions, "t.pengguna_id = $1")
+		args = append(args, penggunaID)
+	}
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
+
+	limit, offset := q.Window()
+	query := fmt.Sprintf(`
+		SELECT %s, %s, %s
+		FROM t_pengguna_trade t
+		JOIN m_pengguna m ON m.id = t.pengguna_id
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, tradeColumns, tradeSortKeyColumns, q.TotalColumn(), strings.Join(conditions, " AND "), q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	var total int
+	trades := make([]model.PenggunaTrade, 0)
+	sortKeys := make(map[int64]tradeSortKeys)
+	for rows.Next() {
+		var trade model.PenggunaTrade
+		var closedAt, updatedAt sql.NullTime
+		var payout sql.NullFloat64
+		var keys tradeSortKeys
+		if err := rows.Scan(
+			&trade.ID,
+			&trade.PenggunaID,
+			&trade.IDPengguna,
+			&trade.ClientTradeID,
+			&trade.DeviceID,
+			&trade.Asset,
+			&trade.Direction,
+			&trade.Amount,
+			&trade.AccountType,
+			&trade.OpenedAt,
+			&closedAt,
+			&trade.Result,
+			&payout,
+			&trade.CreatedAt,
+			&updatedAt,
+			&keys.amount,
+			&keys.profit,
+			&total,
+		); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		if closedAt.Valid {
+			val := closedAt.Time
+			trade.ClosedAt = &val
+		}
+		if payout.Valid {
+			val := payout.Float64
+			trade.Payout = &val
+		}
+		if updatedAt.Valid {
+			val := updatedAt.Time
+			trade.UpdatedAt = &val
+		}
+		sortKeys[trade.ID] = keys
+		trades = append(trades, trade)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(trades)) {
+		if total, err = countTotal(ctx, r.db, "t_pengguna_trade t JOIN m_pengguna m ON m.id = t.pengguna_id", strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	trades, page, err := queryspec.Finish(q, trades, total, func(trade model.PenggunaTrade, field string) any {
+		return penggunaTradeSortValue(trade, sortKeys[trade.ID], field)
+	})
+	return trades, page, spanError(span, err)
+}
+
+func (r *PenggunaTradeRepository) ensurePartition(ctx context.Context, openedAt time.Time) error {
+	start := time.Date(openedAt.UTC().Year(), openedAt.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	name := fmt.Sprintf("t_pengguna_trade_p%s", start.Format("200601"))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.partitions[name] {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s PARTITION OF t_pengguna_trade FOR VALUES FROM ('%s') TO ('%s')`,
+		name, start.Format(time.RFC3339), start.AddDate(0, 1, 0).Format(time.RFC3339),
+	))
+	var pqErr *pq.Error
+	if err != nil && !(errors.As(err, &pqErr) && (pqErr.Code == "42P07" || pqErr.Code == "23505")) {
+		return err
+	}
+	r.partitions[name] = true
+	return nil
+}
+
+func penggunaTradeSortValue(trade model.PenggunaTrade, keys tradeSortKeys, field string) any {
+	switch field {
+	case "opened_at":
+		return trade.OpenedAt
+	case "amount":
+		return keys.amount
+	case "profit":
+		return keys.profit
+	default:
+		return trade.ID
+	}
+}
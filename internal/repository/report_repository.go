@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"koalbot_api/internal/model"
+)
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+func (r *ReportRepository) ClosedTrades(ctx context.Context, penggunaID int64, accountType string, from, to time.Time, fn func(model.TradeOutcome) error) error {
+	ctx, span := startSpan(ctx, "ReportRepository.ClosedTrades")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.pengguna_id, m.id_pengguna, t.closed_at, t.amount, COALESCE(t.payout, 0), t.result
+		FROM t_pengguna_trade t
+		JOIN m_pengguna m ON m.id = t.pengguna_id
+		WHERE t.closed_at >= $1 AND t.closed_at < $2
+			AND t.opened_at < $2
+			AND t.result IS NOT NULL
+			AND t.account_type = $3
+			AND CASE WHEN $4::bigint > 0 THEN t.pengguna_id = $4 ELSE m.active AND m.deleted_at IS NULL END
+		ORDER BY t.closed_at, t.id
+	`, from, to, accountType, penggunaID)
+	if err != nil {
+		return spanError(span, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var outcome model.TradeOutcome
+		if err := rows.Scan(
+			&outcome.PenggunaID,
+			&outcome.IDPengguna,
+			&outcome.ClosedAt,
+			&outcome.Amount,
+			&outcome.Payout,
+			&outcome.Result,
+		); err != nil {
+			return spanError(span, err)
+		}
+		if err := fn(outcome); err != nil {
+			return err
+		}
+	}
+	return spanError(span, rows.Err())
+}
+
+func (r *ReportRepository) BalanceChange(ctx context.Context, penggunaID int64, from, to time.Time) (model.BalanceChange, error) {
+	ctx, span := startSpan(ctx, "ReportRepository.BalanceChange")
+	defer span.End()
+
+	var change model.BalanceChange
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(s.balance), 0), COALESCE(SUM(e.balance), 0), COUNT(e.balance)
+		FROM m_pengguna m
+		LEFT JOIN LATERAL (
+			SELECT balance
+			FROM t_pengguna_balance_history
+			WHERE pengguna_id = m.id AND captured_at < $2
+			ORDER BY captured_at > $1, CASE WHEN captured_at <= $1 THEN captured_at END DESC, captured_at
+			LIMIT 1
+		) s ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT balance
+			FROM t_pengguna_balance_history
+			WHERE pengguna_id = m.id AND captured_at < $2
+			ORDER BY captured_at DESC
+			LIMIT 1
+		) e ON TRUE
+		WHERE CASE WHEN $3::bigint > 0 THEN m.id = $3 ELSE m.active AND m.deleted_at IS NULL END
+		HAVING COUNT(*) > 0
+	`, from, to, penggunaID).Scan(&change.Start, &change.End, &change.Accounts)
+	return change, spanError(span, err)
+}
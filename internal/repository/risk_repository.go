@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"koalbot_api/internal/model"
+)
+
+var ErrRiskPlanNameConflict = errors.New("risk_plan_name_conflict")
+
+const riskRuleColumns = `max_daily_loss, max_trades_per_hour, max_stake_pct, trading_start, trading_end, timezone`
+
+const riskPlanColumns = `id, name, ` + riskRuleColumns + `, created_at, COALESCE(created_by, ''), updated_at, COALESCE(updated_by, '')`
+
+type RiskRepository struct {
+	db *sql.DB
+}
+
+func NewRiskRepository(db *sql.DB) *RiskRepository {
+	return &RiskRepository{db: db}
+}
+
+func (r *RiskRepository) ListPlans(ctx context.Context) ([]model.RiskPlan, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.ListPlans")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+riskPlanColumns+` FROM m_risk_plan ORDER BY name`)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	plans := make([]model.RiskPlan, 0)
+	for rows.Next() {
+		plan, err := scanRiskPlan(rows)
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, spanError(span, rows.Err())
+}
+
+func (r *RiskRepository) GetPlan(ctx context.Context, id int64) (model.RiskPlan, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.GetPlan")
+	defer span.End()
+
+	plan, err := scanRiskPlan(r.db.QueryRowContext(ctx, `SELECT `+riskPlanColumns+` FROM m_risk_plan WHERE id = $1`, id))
+	return plan, spanError(span, err)
+}
+
+func (r *RiskRepository) CreatePlan(ctx context.Context, plan model.RiskPlan) (model.RiskPlan, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.CreatePlan")
+	defer span.End()
+
+	args := append([]any{plan.Name}, riskRuleArgs(plan.Rules)...)
+	created, err := scanRiskPlan(r.db.QueryRowContext(ctx, `
+		INSERT INTO m_risk_plan (name, `+riskRuleColumns+`, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+riskPlanColumns,
+		append(args, plan.CreatedBy)...))
+	return created, spanError(span, riskPlanConflict(err))
+}
+
+func (r *RiskRepository) UpdatePlan(ctx context.Context, plan model.RiskPlan) (model.RiskPlan, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.UpdatePlan")
+	defer span.End()
+
+	args := append([]any{plan.ID, plan.Name}, riskRuleArgs(plan.Rules)...)
+	updated, err := scanRiskPlan(r.db.QueryRowContext(ctx, `
+		UPDATE m_risk_plan
+		SET name = $2, max_daily_loss = $3, max_trades_per_hour = $4, max_stake_pct = $5,
+			trading_start = $6, trading_end = $7, timezone = $8, updated_at = NOW(), updated_by = $9
+		WHERE id = $1
+		RETURNING `+riskPlanColumns,
+		append(args, plan.UpdatedBy)...))
+	return updated, spanError(span, riskPlanConflict(err))
+}
+
+func (r *RiskRepository) DeletePlan(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "RiskRepository.DeletePlan")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM m_risk_plan WHERE id = $1`, id)
+	return spanError(span, affectedOrNoRows(res, err))
+}
+
+func (r *RiskRepository) Get(ctx context.Context, penggunaID int64) (model.PenggunaRisk, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.Get")
+	defer span.End()
+
+	var risk model.PenggunaRisk
+	var planID sql.NullInt64
+	var planName, haltRule, haltReason, updatedBy sql.NullString
+	var haltedAt, updatedAt sql.NullTime
+	var halted sql.NullBool
+	dest := []any{&risk.PenggunaID, &planID, &planName}
+	planRules, scanPlan := riskRuleDest()
+	overrides, scanOverrides := riskRuleDest()
+	dest = append(dest, planRules...)
+	dest = append(dest, overrides...)
+	dest = append(dest, &halted, &haltedAt, &haltRule, &haltReason, &updatedAt, &updatedBy)
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT m.id, r.plan_id, p.name,
+			p.max_daily_loss, p.max_trades_per_hour, p.max_stake_pct, p.trading_start, p.trading_end, p.timezone,
+			r.max_daily_loss, r.max_trades_per_hour, r.max_stake_pct, r.trading_start, r.trading_end, r.timezone,
+			r.halted, r.halted_at, r.halt_rule, r.halt_reason, r.updated_at, r.updated_by
+		FROM m_pengguna m
+		LEFT JOIN t_pengguna_risk r ON r.pengguna_id = m.id
+		LEFT JOIN m_risk_plan p ON p.id = r.plan_id
+		WHERE m.id = $1 AND m.deleted_at IS NULL
+	`, penggunaID).Scan(dest...)
+	if err != nil {
+		return model.PenggunaRisk{}, spanError(span, err)
+	}
+
+	if planID.Valid {
+		val := planID.Int64
+		risk.PlanID = &val
+	}
+	risk.PlanName = planName.String
+	risk.Plan = scanPlan()
+	risk.Overrides = scanOverrides()
+	risk.Halted = halted.Bool
+	if haltedAt.Valid {
+		val := haltedAt.Time
+		risk.HaltedAt = &val
+	}
+	risk.HaltRule = haltRule.String
+	risk.HaltReason = haltReason.String
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		risk.UpdatedAt = &val
+	}
+	risk.UpdatedBy = updatedBy.String
+	return risk, nil
+}
+
+func (r *RiskRepository) Save(ctx context.Context, penggunaID int64, planID *int64, overrides model.RiskRules, updatedBy string) error {
+	ctx, span := startSpan(ctx, "RiskRepository.Save")
+	defer span.End()
+
+	args := append([]any{penggunaID, planID}, riskRuleArgs(overrides)...)
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO t_pengguna_risk (pengguna_id, plan_id, `+riskRuleColumns+`, updated_at, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), $9)
+		ON CONFLICT (pengguna_id) DO UPDATE SET
+			plan_id = EXCLUDED.plan_id,
+			max_daily_loss = EXCLUDED.max_daily_loss,
+			max_trades_per_hour = EXCLUDED.max_trades_per_hour,
+			max_stake_pct = EXCLUDED.max_stake_pct,
+			trading_start = EXCLUDED.trading_start,
+			trading_end = EXCLUDED.trading_end,
+			timezone = EXCLUDED.timezone,
+			updated_at = EXCLUDED.updated_at,
+			updated_by = EXCLUDED.updated_by
+	`, append(args, updatedBy)...)
+	return spanError(span, missingPengguna(err))
+}
+
+func (r *RiskRepository) Halt(ctx context.Context, penggunaID int64, rule, reason string) (bool, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.Halt")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO t_pengguna_risk (pengguna_id, halted, halted_at, halt_rule, halt_reason)
+		VALUES ($1, TRUE, NOW(), $2, $3)
+		ON CONFLICT (pengguna_id) DO UPDATE SET
+			halted = TRUE,
+			halted_at = EXCLUDED.halted_at,
+			halt_rule = EXCLUDED.halt_rule,
+			halt_reason = EXCLUDED.halt_reason
+		WHERE NOT t_pengguna_risk.halted
+	`, penggunaID, rule, reason)
+	if err != nil {
+		return false, spanError(span, missingPengguna(err))
+	}
+	halted, err := res.RowsAffected()
+	return halted > 0, spanError(span, err)
+}
+
+func (r *RiskRepository) Resume(ctx context.Context, penggunaID int64, updatedBy string) (bool, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.Resume")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE t_pengguna_risk
+		SET halted = FALSE, halted_at = NULL, halt_rule = NULL, halt_reason = NULL, updated_at = NOW(), updated_by = $2
+		WHERE pengguna_id = $1 AND halted
+	`, penggunaID, updatedBy)
+	if err != nil {
+		return false, spanError(span, err)
+	}
+	resumed, err := res.RowsAffected()
+	return resumed > 0, spanError(span, err)
+}
+
+func (r *RiskRepository) Exposure(ctx context.Context, penggunaID int64, dayStart, hourStart time.Time) (model.RiskExposure, error) {
+	ctx, span := startSpan(ctx, "RiskRepository.Exposure")
+	defer span.End()
+
+	var exposure model.RiskExposure
+	var balance sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE((
+				SELECT SUM(payout - amount)
+				FROM t_pengguna_trade
+				WHERE pengguna_id = $1 AND account_type = 'real' AND result IS NOT NULL
+					AND closed_at >= $2 AND opened_at >= $2::timestamptz - INTERVAL '1 day'
+			), 0),
+			(
+				SELECT COUNT(*)
+				FROM t_pengguna_trade
+				WHERE pengguna_id = $1 AND account_type = 'real' AND opened_at >= $3
+			),
+			(
+				SELECT balance
+				FROM t_pengguna_detail
+				WHERE pengguna_id = $1
+				ORDER BY id DESC
+				LIMIT 1
+			)
+	`, penggunaID, dayStart, hourStart).Scan(&exposure.DailyProfit, &exposure.HourTrades, &balance)
+	if err != nil {
+		return model.RiskExposure{}, spanError(span, err)
+	}
+	if balance.Valid {
+		val := balance.Float64
+		exposure.Balance = &val
+	}
+	return exposure, nil
+}
+
+func scanRiskPlan(row rowScanner) (model.RiskPlan, error) {
+	var plan model.RiskPlan
+	var updatedAt sql.NullTime
+	rules, scanRules := riskRuleDest()
+	dest := append([]any{&plan.ID, &plan.Name}, rules...)
+	dest = append(dest, &plan.CreatedAt, &plan.CreatedBy, &updatedAt, &plan.UpdatedBy)
+	if err := row.Scan(dest...); err != nil {
+		return model.RiskPlan{}, err
+	}
+	plan.Rules = scanRules()
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		plan.UpdatedAt = &val
+	}
+	return plan, nil
+}
+
+func riskRuleDest() ([]any, func() model.RiskRules) {
+	var dailyLoss, stakePct sql.NullFloat64
+	var tradesPerHour sql.NullInt64
+	var start, end, timezone sql.NullString
+	dest := []any{&dailyLoss, &tradesPerHour, &stakePct, &start, &end, &timezone}
+	return dest, func() model.RiskRules {
+		var rules model.RiskRules
+		if dailyLoss.Valid {
+			val := dailyLoss.Float64
+			rules.MaxDailyLoss = &val
+		}
+		if tradesPerHour.Valid {
+			val := int(tradesPerHour.Int64)
+			rules.MaxTradesPerHour = &val
+		}
+		if stakePct.Valid {
+			val := stakePct.Float64
+			rules.MaxStakePct = &val
+		}
+		if start.Valid {
+			val := start.String
+			rules.TradingStart = &val
+		}
+		if end.Valid {
+			val := end.String
+			rules.TradingEnd = &val
+		}
+		if timezone.Valid {
+			val := timezone.String
+			rules.Timezone = &val
+		}
+		return rules
+	}
+}
+
+func riskRuleArgs(rules model.RiskRules) []any {
+	return []any{
+		rules.MaxDailyLoss,
+		rules.MaxTradesPerHour,
+		rules.MaxStakePct,
+		nullableString(rules.TradingStart),
+		nullableString(rules.TradingEnd),
+		nullableString(rules.Timezone),
+	}
+}
+
+func riskPlanConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrRiskPlanNameConflict
+	}
+	return err
+}
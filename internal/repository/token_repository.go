@@ -15,9 +15,59 @@ func NewTokenRepository(db *sql.DB) *TokenRepository {
 }
 
 func (r *TokenRepository) SaveRefreshToken(ctx context.Context, userUID, token string, lastSeen, expiresAt time.Time) error {
+	ctx, span := startSpan(ctx, "TokenRepository.SaveRefreshToken")
+	defer span.End()
+
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO t_user_token (user_uid, token, last_seen, expires_at)
 		VALUES ($1, $2, $3, $4)
 	`, userUID, token, lastSeen, expiresAt)
-	return err
+	return spanError(span, err)
+}
+
+func (r *TokenRepository) RevokeUser(ctx context.Context, userUID string) (int64, error) {
+	ctx, span := startSpan(ctx, "TokenRepository.RevokeUser")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE m_user
+		SET token_version = token_version + 1
+		WHERE uid = $1
+	`, userUID); err != nil {
+		return 0, spanError(span, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM t_user_token
+		WHERE user_uid = $1
+	`, userUID)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	return revoked, spanError(span, tx.Commit())
+}
+
+func (r *TokenRepository) UserState(ctx context.Context, userUID string) (bool, int, string, error) {
+	ctx, span := startSpan(ctx, "TokenRepository.UserState")
+	defer span.End()
+
+	var active bool
+	var version int
+	var role string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT active AND deleted_at IS NULL, token_version, role
+		FROM m_user
+		WHERE uid = $1
+	`, userUID).Scan(&active, &version, &role)
+	return active, version, role, spanError(span, err)
 }
@@ -1,8 +1,13 @@
 package repository
 
+import "time"
+
 type UpdateMasterPenggunaRequest struct {
-	IDPengguna *int64
-	Telegram   *string
-	Jenis      *string
-	Active     *bool
+	IDPengguna  *int64
+	Telegram    *string
+	Jenis       *string
+	Active      *bool
+	ActiveUntil *time.Time
+
+	ClearActiveUntil bool
 }
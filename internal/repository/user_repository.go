@@ -8,8 +8,27 @@ import (
 	"time"
 
 	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
 )
 
+var UserQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":         "id",
+		"username":   "username",
+		"role":       "role",
+		"active":     "active",
+		"created_at": "created_at",
+		"last_seen":  "COALESCE(last_seen, 'epoch'::timestamptz)",
+	},
+	Filters: map[string]queryspec.Filter{
+		"active":     {Kind: queryspec.Bool, Column: "active"},
+		"created_at": {Kind: queryspec.TimeRange, Column: "created_at"},
+		"last_seen":  {Kind: queryspec.TimeRange, Column: "last_seen"},
+	},
+	Default: []queryspec.Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
 type UserRepository struct {
 	db *sql.DB
 }
@@ -19,12 +38,15 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) FindByUsername(ctx context.Context, username string) (model.User, error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByUsername")
+	defer span.End()
+
 	var user model.User
 	var deletedAt sql.NullTime
 	var lastSeen sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, `
-		SELECT id, uid, username, password, role, active, deleted_at, last_seen
+		SELECT id, uid, username, password, role, active, deleted_at, last_seen, token_version
 		FROM m_user
 		WHERE username = $1
 		LIMIT 1
@@ -37,9 +59,10 @@ func (r *UserRepository) FindByUsername(ctx context.Context, username string) (m
 		&user.Active,
 		&deletedAt,
 		&lastSeen,
+		&user.TokenVersion,
 	)
 	if err != nil {
-		return model.User{}, err
+		return model.User{}, spanError(span, err)
 	}
 	if deletedAt.Valid {
 		t := deletedAt.Time
@@ -53,31 +76,37 @@ func (r *UserRepository) FindByUsername(ctx context.Context, username string) (m
 	return user, nil
 }
 
-func (r *UserRepository) ListUsers(ctx context.Context, search string, limit, offset int) ([]model.User, int, error) {
-	var total int
-	err := r.db.QueryRowContext(ctx, `
-		SELECT COUNT(*)
-		FROM m_user
-		WHERE deleted_at IS NULL
-			AND ($1 = '' OR username ILIKE '%' || $1 || '%')
-	`, search).Scan(&total)
-	if err != nil {
-		return nil, 0, err
+func (r *UserRepository) ListUsers(ctx context.Context, q queryspec.Query, deleted bool) ([]model.User, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "UserRepository.ListUsers")
+	defer span.End()
+
+	conditions := []string{
+		"(deleted_at IS NOT NULL) = $2",
+		"($1 = '' OR username ILIKE '%' || $1 || '%')",
 	}
+	args := []any{q.Search, deleted}
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
 
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, uid, username, role, active, created_at, created_by, updated_at, updated_by, last_seen
+	limit, offset := q.Window()
+	listQuery := fmt.Sprintf(`
+		SELECT id, uid, username, role, active, created_at, created_by, updated_at, updated_by, last_seen, deleted_at, deleted_by, %s
 		FROM m_user
-		WHERE deleted_at IS NULL
-			AND ($1 = '' OR username ILIKE '%' || $1 || '%')
-		ORDER BY id DESC
-		LIMIT $2 OFFSET $3
-	`, search, limit, offset)
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, q.TotalColumn(), strings.Join(conditions, " AND "), q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, listQuery, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, queryspec.Page{}, spanError(span, err)
 	}
 	defer rows.Close()
 
+	var total int
 	users := make([]model.User, 0)
 	for rows.Next() {
 		var user model.User
@@ -85,6 +114,8 @@ func (r *UserRepository) ListUsers(ctx context.Context, search string, limit, of
 		var updatedAt sql.NullTime
 		var updatedBy sql.NullString
 		var lastSeen sql.NullTime
+		var deletedAt sql.NullTime
+		var deletedBy sql.NullString
 
 		if err := rows.Scan(
 			&user.ID,
@@ -97,8 +128,11 @@ func (r *UserRepository) ListUsers(ctx context.Context, search string, limit, of
 			&updatedAt,
 			&updatedBy,
 			&lastSeen,
+			&deletedAt,
+			&deletedBy,
+			&total,
 		); err != nil {
-			return nil, 0, err
+			return nil, queryspec.Page{}, spanError(span, err)
 		}
 
 		if createdBy.Valid {
@@ -117,26 +151,47 @@ func (r *UserRepository) ListUsers(ctx context.Context, search string, limit, of
 			val := lastSeen.Time
 			user.LastSeen = &val
 		}
+		if deletedAt.Valid {
+			val := deletedAt.Time
+			user.DeletedAt = &val
+		}
+		if deletedBy.Valid {
+			val := deletedBy.String
+			user.DeletedBy = &val
+		}
 
 		users = append(users, user)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, queryspec.Page{}, spanError(span, err)
 	}
 
-	return users, total, nil
+	if q.NeedsCount(len(users)) {
+		if total, err = countTotal(ctx, r.db, "m_user", strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	users, page, err := queryspec.Finish(q, users, total, userSortValue)
+	return users, page, spanError(span, err)
 }
 
 func (r *UserRepository) UpdateLastSeen(ctx context.Context, uid string, lastSeen time.Time) error {
+	ctx, span := startSpan(ctx, "UserRepository.UpdateLastSeen")
+	defer span.End()
+
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE m_user
 		SET last_seen = $2, updated_at = NOW()
 		WHERE uid = $1
 	`, uid, lastSeen)
-	return err
+	return spanError(span, err)
 }
 
 func (r *UserRepository) CreateUser(ctx context.Context, username, passwordHash, role, createdBy string) (string, string, error) {
+	ctx, span := startSpan(ctx, "UserRepository.CreateUser")
+	defer span.End()
+
 	var uid string
 	var storedRole string
 	err := r.db.QueryRowContext(ctx, `
@@ -145,12 +200,15 @@ func (r *UserRepository) CreateUser(ctx context.Context, username, passwordHash,
 		RETURNING uid, role
 	`, username, passwordHash, role, createdBy).Scan(&uid, &storedRole)
 	if err != nil {
-		return "", "", err
+		return "", "", spanError(span, err)
 	}
 	return uid, storedRole, nil
 }
 
 func (r *UserRepository) UpdateUser(ctx context.Context, uid string, req UpdateUserRequest) error {
+	ctx, span := startSpan(ctx, "UserRepository.UpdateUser")
+	defer span.End()
+
 	setClauses := make([]string, 0, 5)
 	args := make([]any, 0, 6)
 	argPos := 1
@@ -187,14 +245,95 @@ func (r *UserRepository) UpdateUser(ctx context.Context, uid string, req UpdateU
 	query := fmt.Sprintf("UPDATE m_user SET %s WHERE uid = $%d", strings.Join(setClauses, ", "), argPos)
 
 	_, err := r.db.ExecContext(ctx, query, args...)
-	return err
+	return spanError(span, err)
 }
 
 func (r *UserRepository) SoftDeleteUser(ctx context.Context, uid, deletedBy string) error {
+	ctx, span := startSpan(ctx, "UserRepository.SoftDeleteUser")
+	defer span.End()
+
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE m_user
-		SET deleted_at = NOW(), deleted_by = $2, active = FALSE, updated_at = NOW(), updated_by = $2
+		SET deleted_at = NOW(), deleted_by = $2, active = FALSE, updated_at = NOW(), updated_by = $2,
+			active_before_delete = CASE WHEN deleted_at IS NULL THEN active ELSE active_before_delete END
 		WHERE uid = $1
 	`, uid, deletedBy)
-	return err
+	return spanError(span, err)
+}
+
+func (r *UserRepository) RestoreUser(ctx context.Context, uid, restoredBy string) error {
+	ctx, span := startSpan(ctx, "UserRepository.RestoreUser")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE m_user
+		SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW(), updated_by = $2,
+			active = COALESCE(active_before_delete, active), active_before_delete = NULL
+		WHERE uid = $1 AND deleted_at IS NOT NULL
+	`, uid, restoredBy)
+	if err != nil {
+		return spanError(span, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return spanError(span, err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *UserRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "UserRepository.PurgeDeleted")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM t_user_token
+		WHERE user_uid IN (
+			SELECT uid FROM m_user WHERE deleted_at < $1
+		)
+	`, before); err != nil {
+		return 0, spanError(span, err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM m_user
+		WHERE deleted_at < $1
+	`, before)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+
+	return purged, spanError(span, tx.Commit())
+}
+
+func userSortValue(user model.User, field string) any {
+	switch field {
+	case "username":
+		return user.Username
+	case "role":
+		return user.Role
+	case "active":
+		return user.Active
+	case "created_at":
+		return user.CreatedAt
+	case "last_seen":
+		if user.LastSeen == nil {
+			return time.Unix(0, 0)
+		}
+		return *user.LastSeen
+	default:
+		return user.ID
+	}
 }
@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+)
+
+var WebhookDeliveryQuery = &queryspec.Schema{
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	Filters: map[string]queryspec.Filter{
+		"status":     {Kind: queryspec.Equal, Column: "status"},
+		"event":      {Kind: queryspec.Equal, Column: "event"},
+		"created_at": {Kind: queryspec.TimeRange, Column: "created_at"},
+	},
+	Default: []queryspec.Sort{{Field: "id", Desc: true}},
+	Key:     "id",
+}
+
+const webhookColumns = `id, name, url, secret, events, active, created_at, COALESCE(created_by, ''), updated_at, COALESCE(updated_by, '')`
+
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+	d.last_status_code, COALESCE(d.last_error, ''), COALESCE(d.last_response, ''), d.last_duration_ms, d.redelivery_of,
+	d.created_at, d.updated_at, d.delivered_at`
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]model.Webhook, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.List")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM m_webhook ORDER BY id`)
+	if err != nil {
+		return nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, spanError(span, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, spanError(span, rows.Err())
+}
+
+func (r *WebhookRepository) Get(ctx context.Context, id int64) (model.Webhook, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.Get")
+	defer span.End()
+
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM m_webhook WHERE id = $1`, id))
+	return webhook, spanError(span, err)
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.Create")
+	defer span.End()
+
+	created, err := scanWebhook(r.db.QueryRowContext(ctx, `
+		INSERT INTO m_webhook (name, url, secret, events, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+webhookColumns,
+		webhook.Name, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active, webhook.CreatedBy))
+	return created, spanError(span, err)
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.Update")
+	defer span.End()
+
+	updated, err := scanWebhook(r.db.QueryRowContext(ctx, `
+		UPDATE m_webhook
+		SET name = $2, url = $3, secret = $4, events = $5, active = $6, updated_at = NOW(), updated_by = $7
+		WHERE id = $1
+		RETURNING `+webhookColumns,
+		webhook.ID, webhook.Name, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active, webhook.UpdatedBy))
+	return updated, spanError(span, err)
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "WebhookRepository.Delete")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM m_webhook WHERE id = $1`, id)
+	return spanError(span, affectedOrNoRows(res, err))
+}
+
+func (r *WebhookRepository) Dispatch(ctx context.Context, limit int) (int64, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.Dispatch")
+	defer span.End()
+
+	res, err := r.db.ExecContext(ctx, `
+		WITH due AS (
+			SELECT id, event, data, created_at
+			FROM t_webhook_event
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), fanout AS (
+			INSERT INTO t_webhook_delivery (webhook_id, event_id, event, payload)
+			SELECT w.id, e.id, e.event, jsonb_build_object(
+				'id', 'evt_' || e.id,
+				'event', e.event,
+				'created_at', e.created_at,
+				'data', e.data
+			)
+			FROM due e
+			JOIN m_webhook w ON w.active AND (cardinality(w.events) = 0 OR e.event = ANY(w.events))
+		)
+		UPDATE t_webhook_event
+		SET dispatched_at = NOW()
+		WHERE id IN (SELECT id FROM due)
+	`, limit)
+	if err != nil {
+		return 0, spanError(span, err)
+	}
+	dispatched, err := res.RowsAffected()
+	return dispatched, spanError(span, err)
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, webhookID int64, event string, payload []byte) (model.WebhookDelivery, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.Enqueue")
+	defer span.End()
+
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, `
+		INSERT INTO t_webhook_delivery AS d (webhook_id, event, payload)
+		VALUES ($1, $2, $3::jsonb)
+		RETURNING `+deliveryColumns,
+		webhookID, event, string(payload)))
+	return delivery, spanError(span, missingPengguna(err))
+}
+
+func (r *WebhookRepository) Redeliver(ctx context.Context, webhookID, deliveryID int64) (model.WebhookDelivery, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.Redeliver")
+	defer span.End()
+
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, `
+		INSERT INTO t_webhook_delivery AS d (webhook_id, event_id, event, payload, redelivery_of)
+		SELECT webhook_id, event_id, event, payload, id
+		FROM t_webhook_delivery
+		WHERE id = $2 AND webhook_id = $1
+		RETURNING `+deliveryColumns,
+		webhookID, deliveryID))
+	return delivery, spanError(span, err)
+}
+
+func (r *WebhookRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, []model.Webhook, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.Claim")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE t_webhook_delivery d
+		SET status = 'delivering', attempts = d.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second', updated_at = NOW()
+		FROM m_webhook w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT q.id
+			FROM t_webhook_delivery q
+			JOIN m_webhook qw ON qw.id = q.webhook_id AND qw.active
+			WHERE q.status IN ('pending', 'delivering') AND q.next_attempt_at <= NOW()
+			ORDER BY q.next_attempt_at, q.id
+			LIMIT $1
+			FOR UPDATE OF q SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns+`, w.url, w.secret`,
+		limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, nil, spanError(span, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	targets := make([]model.Webhook, 0)
+	for rows.Next() {
+		var target model.Webhook
+		delivery, err := scanDelivery(rows, &target.URL, &target.Secret)
+		if err != nil {
+			return nil, nil, spanError(span, err)
+		}
+		target.ID = delivery.WebhookID
+		deliveries = append(deliveries, delivery)
+		targets = append(targets, target)
+	}
+	return deliveries, targets, spanError(span, rows.Err())
+}
+
+func (r *WebhookRepository) Record(ctx context.Context, delivery model.WebhookDelivery) error {
+	ctx, span := startSpan(ctx, "WebhookRepository.Record")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE t_webhook_delivery
+		SET status = $2, next_attempt_at = $3, last_status_code = $4, last_error = NULLIF($5, ''),
+			last_response = NULLIF($6, ''), last_duration_ms = $7, updated_at = NOW(),
+			delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE delivered_at END
+		WHERE id = $1 AND status = 'delivering'
+	`, delivery.ID, delivery.Status, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError,
+		delivery.LastResponse, delivery.LastDurationMS)
+	return spanError(span, err)
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int64, q queryspec.Query) ([]model.WebhookDelivery, queryspec.Page, error) {
+	ctx, span := startSpan(ctx, "WebhookRepository.ListDeliveries")
+	defer span.End()
+
+	conditions := []string{"webhook_id = $1"}
+	args := []any{webhookID}
+	specConditions, specArgs := q.Conditions(len(args) + 1)
+	conditions = append(conditions, specConditions...)
+	args = append(args, specArgs...)
+	argPos := len(args) + 1
+
+	limit, offset := q.Window()
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM t_webhook_delivery d
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, deliveryColumns, q.TotalColumn(), strings.Join(conditions, " AND "), q.OrderBy(), argPos, argPos+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+	defer rows.Close()
+
+	var total int
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows, &total)
+		if err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryspec.Page{}, spanError(span, err)
+	}
+
+	if q.NeedsCount(len(deliveries)) {
+		if total, err = countTotal(ctx, r.db, "t_webhook_delivery d", strings.Join(conditions, " AND "), args[:argPos-1]); err != nil {
+			return nil, queryspec.Page{}, spanError(span, err)
+		}
+	}
+
+	deliveries, page, err := queryspec.Finish(q, deliveries, total, webhookDeliverySortValue)
+	return deliveries, page, spanError(span, err)
+}
+
+func scanWebhook(row rowScanner) (model.Webhook, error) {
+	var webhook model.Webhook
+	var events pq.StringArray
+	var updatedAt sql.NullTime
+	if err := row.Scan(
+		&webhook.ID,
+		&webhook.Name,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.CreatedBy,
+		&updatedAt,
+		&webhook.UpdatedBy,
+	); err != nil {
+		return model.Webhook{}, err
+	}
+	webhook.Events = []string(events)
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		webhook.UpdatedAt = &val
+	}
+	return webhook, nil
+}
+
+func scanDelivery(row rowScanner, extra ...any) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	var payload []byte
+	var eventID, redeliveryOf sql.NullInt64
+	var statusCode, durationMS sql.NullInt32
+	var updatedAt, deliveredAt sql.NullTime
+	dest := append([]any{
+		&delivery.ID,
+		&delivery.WebhookID,
+		&eventID,
+		&delivery.Event,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&statusCode,
+		&delivery.LastError,
+		&delivery.LastResponse,
+		&durationMS,
+		&redeliveryOf,
+		&delivery.CreatedAt,
+		&updatedAt,
+		&deliveredAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	delivery.Payload = payload
+	if eventID.Valid {
+		val := eventID.Int64
+		delivery.EventID = &val
+	}
+	if redeliveryOf.Valid {
+		val := redeliveryOf.Int64
+		delivery.RedeliveryOf = &val
+	}
+	if statusCode.Valid {
+		val := int(statusCode.Int32)
+		delivery.LastStatusCode = &val
+	}
+	if durationMS.Valid {
+		val := int(durationMS.Int32)
+		delivery.LastDurationMS = &val
+	}
+	if updatedAt.Valid {
+		val := updatedAt.Time
+		delivery.UpdatedAt = &val
+	}
+	if deliveredAt.Valid {
+		val := deliveredAt.Time
+		delivery.DeliveredAt = &val
+	}
+	return delivery, nil
+}
+
+func webhookDeliverySortValue(delivery model.WebhookDelivery, field string) any {
+	switch field {
+	case "created_at":
+		return delivery.CreatedAt
+	default:
+		return delivery.ID
+	}
+}
@@ -0,0 +1,57 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"koalbot_api/internal/logging"
+)
+
+type Purger interface {
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Job struct {
+	name     string
+	purger   Purger
+	after    time.Duration
+	interval time.Duration
+}
+
+func NewJob(name string, purger Purger, after, interval time.Duration) *Job {
+	return &Job{
+		name:     name,
+		purger:   purger,
+		after:    after,
+		interval: interval,
+	}
+}
+
+func (j *Job) Run(ctx context.Context) {
+	j.runOnce(ctx)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.runOnce(ctx)
+		}
+	}
+}
+
+func (j *Job) runOnce(ctx context.Context) {
+	before := time.Now().Add(-j.after)
+	purged, err := j.purger.PurgeDeleted(ctx, before)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.Errorf("retention: purge %s failed: %v", j.name, err)
+		}
+		return
+	}
+	if purged > 0 {
+		logging.Infof("retention: purged %d %s deleted before %s", purged, j.name, before.UTC().Format(time.RFC3339))
+	}
+}
@@ -1,12 +1,15 @@
 package router
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"koalbot_api/internal/handler"
+	"koalbot_api/internal/logging"
 	"koalbot_api/internal/middleware"
 )
 
@@ -20,44 +23,198 @@ type Options struct {
 	JWTSecret            string
 	CORSAllowedOrigins   []string
 	CORSAllowCredentials bool
+	TracingServiceName   string
 }
 
-func New(auth *handler.AuthHandler, v1Login *handler.V1LoginHandler, users *handler.UserHandler, masterPengguna *handler.MasterPenggunaHandler, stream *handler.StreamHandler, health *handler.HealthHandler, opts Options) *gin.Engine {
+type Runtime struct {
+	cors           *middleware.CORS
+	rateLimit      *middleware.RateLimiter
+	loginRateLimit *middleware.RateLimiter
+	v1RateLimit    *middleware.RateLimiter
+}
+
+func (rt *Runtime) Apply(opts Options) {
+	rt.cors.Update(buildCORSConfig(opts))
+	rt.rateLimit.SetLimit(opts.RateLimitRPS, opts.RateLimitBurst)
+	rt.loginRateLimit.SetLimit(opts.LoginRateLimitRPS, opts.LoginRateLimitBurst)
+	rt.v1RateLimit.SetLimit(opts.LoginRateLimitRPS, opts.LoginRateLimitBurst)
+}
+
+type Handlers struct {
+	Auth            *handler.AuthHandler
+	V1Login         *handler.V1LoginHandler
+	V1Heartbeat     *handler.V1HeartbeatHandler
+	V1Commands      *handler.V1CommandHandler
+	V1Config        *handler.V1ConfigHandler
+	V1Trades        *handler.V1TradeHandler
+	Users           *handler.UserHandler
+	MasterPengguna  *handler.MasterPenggunaHandler
+	PenggunaLogins  *handler.PenggunaLoginHandler
+	PenggunaDevices *handler.PenggunaDeviceHandler
+	Presence        *handler.PresenceHandler
+	Commands        *handler.PenggunaCommandHandler
+	BotConfig       *handler.BotConfigHandler
+	ClientVersions  *handler.ClientVersionHandler
+	Trades          *handler.TradeHandler
+	Reports         *handler.ReportHandler
+	BalanceHistory  *handler.BalanceHistoryHandler
+	Risk            *handler.RiskHandler
+	Webhooks        *handler.WebhookHandler
+	Payments        *handler.PaymentHandler
+	Dashboard       *handler.DashboardHandler
+	Stream          *handler.StreamHandler
+	Health          *handler.HealthHandler
+}
+
+func New(h Handlers, sessions middleware.SessionVerifier, admins middleware.TokenVerifier, opts Options) (*gin.Engine, *Runtime) {
+	rt := &Runtime{
+		cors:           middleware.NewCORS(buildCORSConfig(opts)),
+		rateLimit:      middleware.NewRateLimiter(opts.RateLimitRPS, opts.RateLimitBurst, 10*time.Minute),
+		loginRateLimit: middleware.NewRateLimiter(opts.LoginRateLimitRPS, opts.LoginRateLimitBurst, 10*time.Minute),
+		v1RateLimit:    middleware.NewRateLimiter(opts.LoginRateLimitRPS, opts.LoginRateLimitBurst, 10*time.Minute),
+	}
+
 	engine := gin.New()
-	engine.Use(gin.Logger(), gin.Recovery())
-	engine.Use(cors.New(buildCORSConfig(opts)))
+	engine.Use(gin.LoggerWithConfig(gin.LoggerConfig{Skip: skipAccessLog}), gin.Recovery())
+	engine.Use(otelgin.Middleware(opts.TracingServiceName))
+	engine.Use(rt.cors.Handler())
 	engine.Use(middleware.BodyLimit(opts.MaxBodyBytes))
-	engine.Use(middleware.RateLimit(opts.RateLimitRPS, opts.RateLimitBurst, 10*time.Minute))
+	engine.Use(rt.rateLimit.Handler())
 
 	if err := engine.SetTrustedProxies(opts.TrustedProxies); err != nil {
 		panic(err)
 	}
 
-	engine.GET("/healthz", health.Health)
-	engine.GET("/status/stream", stream.StatusStream)
-	engine.POST("/login", middleware.RateLimit(opts.LoginRateLimitRPS, opts.LoginRateLimitBurst, 10*time.Minute), auth.Login)
-	engine.POST("/v1/login", middleware.RateLimit(opts.LoginRateLimitRPS, opts.LoginRateLimitBurst, 10*time.Minute), v1Login.Login)
+	engine.GET("/healthz", h.Health.Health)
+	engine.GET("/livez", h.Health.Live)
+	engine.GET("/readyz", h.Health.Ready)
+	engine.GET("/status/stream", h.Stream.StatusStream)
+	engine.POST("/login", rt.loginRateLimit.Handler(), h.Auth.Login)
+	engine.POST("/v1/login", rt.v1RateLimit.Handler(), h.V1Login.Login)
+	engine.POST("/payments/webhook/:provider", h.Payments.Webhook)
+
+	v1Group := engine.Group("/v1")
+	v1Group.Use(middleware.PenggunaAuthMiddleware(opts.JWTSecret, sessions))
+	v1Group.POST("/heartbeat", h.V1Heartbeat.Heartbeat)
+	v1Group.GET("/commands", h.V1Commands.Poll)
+	v1Group.GET("/commands/stream", h.V1Commands.Stream)
+	v1Group.POST("/commands/:id/ack", h.V1Commands.Ack)
+	v1Group.GET("/config", h.V1Config.Get)
+	v1Group.POST("/trades", h.V1Trades.Report)
 
 	usersGroup := engine.Group("/users")
-	usersGroup.Use(middleware.AuthMiddleware(opts.JWTSecret), middleware.RequireAdmin())
-	usersGroup.POST("", users.Register)
-	usersGroup.GET("", users.List)
-	usersGroup.PUT("/:uid", users.Update)
-	usersGroup.DELETE("/:uid", users.Delete)
+	usersGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	usersGroup.POST("", h.Users.Register)
+	usersGroup.GET("", h.Users.List)
+	usersGroup.PUT("/:uid", h.Users.Update)
+	usersGroup.DELETE("/:uid", h.Users.Delete)
+	usersGroup.POST("/:uid/restore", h.Users.Restore)
 
 	masterPenggunaGroup := engine.Group("/master-pengguna")
-	masterPenggunaGroup.Use(middleware.AuthMiddleware(opts.JWTSecret), middleware.RequireAdmin())
-	masterPenggunaGroup.POST("", masterPengguna.Create)
-	masterPenggunaGroup.GET("", masterPengguna.List)
-	masterPenggunaGroup.GET("/:id", masterPengguna.Get)
-	masterPenggunaGroup.PUT("/:id", masterPengguna.Update)
-	masterPenggunaGroup.DELETE("/:id", masterPengguna.Delete)
+	masterPenggunaGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	masterPenggunaGroup.POST("", h.MasterPengguna.Create)
+	masterPenggunaGroup.GET("", h.MasterPengguna.List)
+	masterPenggunaGroup.POST("/import", h.MasterPengguna.Import)
+	masterPenggunaGroup.GET("/export", h.MasterPengguna.Export)
+	masterPenggunaGroup.POST("/bulk", h.MasterPengguna.Bulk)
+	masterPenggunaGroup.GET("/suggest", h.MasterPengguna.Suggest)
+	masterPenggunaGroup.GET("/alerts", h.PenggunaLogins.Alerts)
+	masterPenggunaGroup.GET("/:id", h.MasterPengguna.Get)
+	masterPenggunaGroup.PUT("/:id", h.MasterPengguna.Update)
+	masterPenggunaGroup.DELETE("/:id", h.MasterPengguna.Delete)
+	masterPenggunaGroup.POST("/:id/restore", h.MasterPengguna.Restore)
+	masterPenggunaGroup.GET("/:id/logins", h.PenggunaLogins.List)
+	masterPenggunaGroup.GET("/:id/devices", h.PenggunaDevices.List)
+	masterPenggunaGroup.DELETE("/:id/devices/:device_id", h.PenggunaDevices.Unbind)
+	masterPenggunaGroup.GET("/:id/presence", h.Presence.Get)
+	masterPenggunaGroup.POST("/:id/commands", h.Commands.Enqueue)
+	masterPenggunaGroup.GET("/:id/commands", h.Commands.List)
+	masterPenggunaGroup.POST("/:id/commands/:command_id/cancel", h.Commands.Cancel)
+	masterPenggunaGroup.GET("/:id/config", h.BotConfig.Current)
+	masterPenggunaGroup.PUT("/:id/config", h.BotConfig.Set)
+	masterPenggunaGroup.GET("/:id/config/history", h.BotConfig.History)
+	masterPenggunaGroup.POST("/:id/config/rollback", h.BotConfig.Rollback)
+	masterPenggunaGroup.GET("/:id/trades", h.Trades.ListByPengguna)
+	masterPenggunaGroup.GET("/:id/report", h.Reports.PenggunaPerformance)
+	masterPenggunaGroup.GET("/:id/balance-history", h.BalanceHistory.Series)
+	masterPenggunaGroup.GET("/:id/risk", h.Risk.Get)
+	masterPenggunaGroup.PUT("/:id/risk", h.Risk.Set)
+	masterPenggunaGroup.POST("/:id/risk/halt", h.Risk.Halt)
+	masterPenggunaGroup.POST("/:id/risk/resume", h.Risk.Resume)
+
+	botConfigGroup := engine.Group("/bot-config")
+	botConfigGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	botConfigGroup.GET("/schema", h.BotConfig.Schema)
+	botConfigGroup.GET("/templates", h.BotConfig.ListTemplates)
+	botConfigGroup.POST("/templates", h.BotConfig.CreateTemplate)
+	botConfigGroup.GET("/templates/:template_id", h.BotConfig.GetTemplate)
+	botConfigGroup.PUT("/templates/:template_id", h.BotConfig.UpdateTemplate)
+	botConfigGroup.DELETE("/templates/:template_id", h.BotConfig.DeleteTemplate)
+	botConfigGroup.POST("/templates/:template_id/assign", h.BotConfig.AssignTemplate)
+
+	clientVersionGroup := engine.Group("/client-versions")
+	clientVersionGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	clientVersionGroup.GET("", h.ClientVersions.List)
+	clientVersionGroup.PUT("/:device_type", h.ClientVersions.Set)
+	clientVersionGroup.DELETE("/:device_type", h.ClientVersions.Delete)
+
+	riskPlanGroup := engine.Group("/risk-plans")
+	riskPlanGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	riskPlanGroup.GET("", h.Risk.ListPlans)
+	riskPlanGroup.POST("", h.Risk.CreatePlan)
+	riskPlanGroup.GET("/:plan_id", h.Risk.GetPlan)
+	riskPlanGroup.PUT("/:plan_id", h.Risk.UpdatePlan)
+	riskPlanGroup.DELETE("/:plan_id", h.Risk.DeletePlan)
+
+	webhookGroup := engine.Group("/webhooks")
+	webhookGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	webhookGroup.GET("", h.Webhooks.List)
+	webhookGroup.POST("", h.Webhooks.Create)
+	webhookGroup.GET("/events", h.Webhooks.Events)
+	webhookGroup.GET("/:webhook_id", h.Webhooks.Get)
+	webhookGroup.PUT("/:webhook_id", h.Webhooks.Update)
+	webhookGroup.DELETE("/:webhook_id", h.Webhooks.Delete)
+	webhookGroup.POST("/:webhook_id/test", h.Webhooks.Test)
+	webhookGroup.GET("/:webhook_id/deliveries", h.Webhooks.Deliveries)
+	webhookGroup.POST("/:webhook_id/deliveries/:delivery_id/redeliver", h.Webhooks.Redeliver)
+
+	paymentGroup := engine.Group("/payments")
+	paymentGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	paymentGroup.GET("", h.Payments.List)
+
+	tradeGroup := engine.Group("/trades")
+	tradeGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	tradeGroup.GET("", h.Trades.List)
+
+	reportGroup := engine.Group("/reports")
+	reportGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	reportGroup.GET("/performance", h.Reports.Performance)
+	reportGroup.GET("/traders", h.Reports.Traders)
+
+	presenceGroup := engine.Group("/presence")
+	presenceGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	presenceGroup.GET("", h.Presence.Online)
+	presenceGroup.GET("/stream", h.Presence.Stream)
 
 	dashboardGroup := engine.Group("/dashboard")
-	dashboardGroup.Use(middleware.AuthMiddleware(opts.JWTSecret), middleware.RequireAdmin())
-	dashboardGroup.GET("/summary", masterPengguna.Summary)
+	dashboardGroup.Use(middleware.AuthMiddleware(opts.JWTSecret, admins), middleware.RequireAdmin())
+	dashboardGroup.GET("/summary", h.Dashboard.Summary)
+	dashboardGroup.GET("/breakdown", h.Dashboard.Breakdown)
+	dashboardGroup.GET("/timeseries", h.Dashboard.Timeseries)
 
-	return engine
+	return engine, rt
+}
+
+func skipAccessLog(c *gin.Context) bool {
+	status := c.Writer.Status()
+	switch {
+	case status >= 500:
+		return !logging.Enabled(slog.LevelError)
+	case status >= 400:
+		return !logging.Enabled(slog.LevelWarn)
+	default:
+		return !logging.Enabled(slog.LevelInfo)
+	}
 }
 
 func buildCORSConfig(opts Options) cors.Config {
@@ -65,8 +222,8 @@ func buildCORSConfig(opts Options) cors.Config {
 		return cors.Config{
 			AllowAllOrigins:  true,
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Content-Type", "Authorization", "Device-Id", "Device-Type", "Authorization-Token"},
-			ExposeHeaders:    []string{"Authorization"},
+			AllowHeaders:     []string{"Content-Type", "Authorization", "Device-Id", "Device-Type", "Authorization-Token", "If-None-Match", "Client-Version"},
+			ExposeHeaders:    []string{"Authorization", "ETag"},
 			AllowCredentials: false,
 			MaxAge:           12 * time.Hour,
 		}
@@ -75,8 +232,8 @@ func buildCORSConfig(opts Options) cors.Config {
 	return cors.Config{
 		AllowOrigins:     opts.CORSAllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "Device-Id", "Device-Type", "Authorization-Token"},
-		ExposeHeaders:    []string{"Authorization"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "Device-Id", "Device-Type", "Authorization-Token", "If-None-Match", "Client-Version"},
+		ExposeHeaders:    []string{"Authorization", "ETag"},
 		AllowCredentials: opts.CORSAllowCredentials,
 		MaxAge:           12 * time.Hour,
 	}
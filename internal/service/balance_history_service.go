@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+)
+
+const (
+	ResolutionAuto = "auto"
+
+	maxBalancePoints = 500
+	maxBalanceRange  = 366 * 24 * time.Hour
+)
+
+var ErrInvalidResolution = errors.New("invalid_resolution")
+
+var balanceResolutions = []struct {
+	name string
+	step time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"30m", 30 * time.Minute},
+	{"1h", time.Hour},
+	{"4h", 4 * time.Hour},
+	{"12h", 12 * time.Hour},
+	{"1d", 24 * time.Hour},
+}
+
+type BalanceHistoryService struct {
+	repo *repository.BalanceHistoryRepository
+}
+
+func NewBalanceHistoryService(repo *repository.BalanceHistoryRepository) *BalanceHistoryService {
+	return &BalanceHistoryService{repo: repo}
+}
+
+func (s *BalanceHistoryService) Series(ctx context.Context, penggunaID int64, from, to time.Time, resolution string) ([]model.BalancePoint, string, error) {
+	if !to.After(from) || to.Sub(from) > maxBalanceRange {
+		return nil, "", ErrInvalidRange
+	}
+
+	resolution, step, err := pickResolution(to.Sub(from), resolution)
+	if err != nil {
+		return nil, "", err
+	}
+
+	points, err := s.repo.Series(ctx, penggunaID, from, to, step)
+	return points, resolution, err
+}
+
+func pickResolution(span time.Duration, resolution string) (string, time.Duration, error) {
+	for _, r := range balanceResolutions {
+		if resolution == ResolutionAuto && span/r.step <= maxBalancePoints {
+			return r.name, r.step, nil
+		}
+		if resolution == r.name {
+			if span/r.step > maxBalancePoints {
+				return "", 0, ErrInvalidResolution
+			}
+			return r.name, r.step, nil
+		}
+	}
+	return "", 0, ErrInvalidResolution
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPickResolution(t *testing.T) {
+	tests := []struct {
+		span       time.Duration
+		resolution string
+		want       string
+		step       time.Duration
+		err        error
+	}{
+		{span: time.Hour, resolution: ResolutionAuto, want: "1m", step: time.Minute},
+		{span: 500 * time.Minute, resolution: ResolutionAuto, want: "1m", step: time.Minute},
+		{span: 501 * time.Minute, resolution: ResolutionAuto, want: "5m", step: 5 * time.Minute},
+		{span: 7 * 24 * time.Hour, resolution: ResolutionAuto, want: "30m", step: 30 * time.Minute},
+		{span: 30 * 24 * time.Hour, resolution: ResolutionAuto, want: "4h", step: 4 * time.Hour},
+		{span: 366 * 24 * time.Hour, resolution: ResolutionAuto, want: "1d", step: 24 * time.Hour},
+		{span: 24 * time.Hour, resolution: "1h", want: "1h", step: time.Hour},
+		{span: 24 * time.Hour, resolution: "1d", want: "1d", step: 24 * time.Hour},
+		{span: 24 * time.Hour, resolution: "1m", err: ErrInvalidResolution},
+		{span: time.Hour, resolution: "2h", err: ErrInvalidResolution},
+		{span: time.Hour, resolution: "", err: ErrInvalidResolution},
+	}
+	for _, tt := range tests {
+		got, step, err := pickResolution(tt.span, tt.resolution)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("pickResolution(%s, %q) error = %v, want %v", tt.span, tt.resolution, err, tt.err)
+			continue
+		}
+		if got != tt.want || step != tt.step {
+			t.Errorf("pickResolution(%s, %q) = %q, %s; want %q, %s", tt.span, tt.resolution, got, step, tt.want, tt.step)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"koalbot_api/internal/botconfig"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+)
+
+var ErrTemplateNameRequired = errors.New("name_required")
+
+type BotConfigService struct {
+	repo *repository.BotConfigRepository
+}
+
+func NewBotConfigService(repo *repository.BotConfigRepository) *BotConfigService {
+	return &BotConfigService{repo: repo}
+}
+
+func ConfigETag(cfg model.PenggunaConfig) string {
+	sum := sha256.Sum256(cfg.Config)
+	return fmt.Sprintf(`"%d-%s"`, cfg.Version, hex.EncodeToString(sum[:8]))
+}
+
+func (s *BotConfigService) ListTemplates(ctx context.Context) ([]model.BotConfigTemplate, error) {
+	return s.repo.ListTemplates(ctx)
+}
+
+func (s *BotConfigService) GetTemplate(ctx context.Context, id int64) (model.BotConfigTemplate, error) {
+	return s.repo.GetTemplate(ctx, id)
+}
+
+func (s *BotConfigService) CreateTemplate(ctx context.Context, name, description string, config json.RawMessage, createdBy string) (model.BotConfigTemplate, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.BotConfigTemplate{}, ErrTemplateNameRequired
+	}
+	normalized, err := botconfig.Validate(config)
+	if err != nil {
+		return model.BotConfigTemplate{}, err
+	}
+	return s.repo.CreateTemplate(ctx, model.BotConfigTemplate{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Config:      normalized,
+		CreatedBy:   createdBy,
+	})
+}
+
+func (s *BotConfigService) UpdateTemplate(ctx context.Context, id int64, name, description *string, config json.RawMessage, updatedBy string) (model.BotConfigTemplate, error) {
+	template, err := s.repo.GetTemplate(ctx, id)
+	if err != nil {
+		return model.BotConfigTemplate{}, err
+	}
+	if name != nil {
+		template.Name = strings.TrimSpace(*name)
+		if template.Name == "" {
+			return model.BotConfigTemplate{}, ErrTemplateNameRequired
+		}
+	}
+	if description != nil {
+		template.Description = strings.TrimSpace(*description)
+	}
+	if len(config) > 0 {
+		normalized, err := botconfig.Validate(config)
+		if err != nil {
+			return model.BotConfigTemplate{}, err
+		}
+		template.Config = normalized
+	}
+	template.UpdatedBy = updatedBy
+	return s.repo.UpdateTemplate(ctx, template)
+}
+
+func (s *BotConfigService) DeleteTemplate(ctx context.Context, id int64) error {
+	return s.repo.DeleteTemplate(ctx, id)
+}
+
+func (s *BotConfigService) AssignTemplate(ctx context.Context, templateID int64, ids []int64, createdBy string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, ErrBulkNoTarget
+	}
+	if len(ids) > maxBulkIDs {
+		return 0, ErrBulkTooManyIDs
+	}
+	if _, err := s.repo.GetTemplate(ctx, templateID); err != nil {
+		return 0, err
+	}
+	return s.repo.AssignTemplate(ctx, templateID, uniqueIDs(ids), createdBy)
+}
+
+func (s *BotConfigService) Current(ctx context.Context, penggunaID int64) (model.PenggunaConfig, error) {
+	return s.repo.Current(ctx, penggunaID)
+}
+
+func (s *BotConfigService) History(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaConfig, queryspec.Page, error) {
+	return s.repo.History(ctx, penggunaID, q)
+}
+
+func (s *BotConfigService) Set(ctx context.Context, penggunaID int64, config json.RawMessage, note, createdBy string) (model.PenggunaConfig, error) {
+	normalized, err := botconfig.Validate(config)
+	if err != nil {
+		return model.PenggunaConfig{}, err
+	}
+	return s.repo.Save(ctx, model.PenggunaConfig{
+		PenggunaID: penggunaID,
+		Config:     normalized,
+		Source:     model.ConfigSourceManual,
+		Note:       strings.TrimSpace(note),
+		CreatedBy:  createdBy,
+	})
+}
+
+func (s *BotConfigService) Rollback(ctx context.Context, penggunaID int64, version int, note, createdBy string) (model.PenggunaConfig, error) {
+	target, err := s.repo.GetVersion(ctx, penggunaID, version)
+	if err != nil {
+		return model.PenggunaConfig{}, err
+	}
+	return s.repo.Save(ctx, model.PenggunaConfig{
+		PenggunaID: penggunaID,
+		Config:     target.Config,
+		Source:     model.ConfigSourceRollback,
+		TemplateID: target.TemplateID,
+		RollbackOf: &target.Version,
+		Note:       strings.TrimSpace(note),
+		CreatedBy:  createdBy,
+	})
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+)
+
+const (
+	ClientVersionOK          = "ok"
+	ClientVersionRecommended = "update_recommended"
+	ClientVersionOutdated    = "outdated"
+)
+
+var (
+	ErrInvalidVersion     = errors.New("invalid_version")
+	ErrVersionOrder       = errors.New("recommended_below_min")
+	ErrInvalidDownloadURL = errors.New("invalid_download_url")
+	ErrDeviceTypeRequired = errors.New("device_type_required")
+)
+
+type ClientVersionCheck struct {
+	Status string
+	Policy model.ClientVersionPolicy
+}
+
+type ClientVersionService struct {
+	repo *repository.ClientVersionRepository
+}
+
+func NewClientVersionService(repo *repository.ClientVersionRepository) *ClientVersionService {
+	return &ClientVersionService{repo: repo}
+}
+
+func (s *ClientVersionService) Check(ctx context.Context, deviceType, version string) (ClientVersionCheck, error) {
+	policy, err := s.repo.Get(ctx, strings.ToLower(strings.TrimSpace(deviceType)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ClientVersionCheck{Status: ClientVersionOK}, nil
+		}
+		return ClientVersionCheck{}, err
+	}
+
+	check := ClientVersionCheck{Status: ClientVersionOK, Policy: policy}
+	current, valid := parseVersion(version)
+	switch {
+	case policy.MinVersion != "" && (!valid || compareVersions(current, mustParseVersion(policy.MinVersion)) < 0):
+		check.Status = ClientVersionOutdated
+	case policy.RecommendedVersion != "" && (!valid || compareVersions(current, mustParseVersion(policy.RecommendedVersion)) < 0):
+		check.Status = ClientVersionRecommended
+	}
+	return check, nil
+}
+
+func (s *ClientVersionService) List(ctx context.Context) ([]model.ClientVersionPolicy, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *ClientVersionService) Set(ctx context.Context, policy model.ClientVersionPolicy) (model.ClientVersionPolicy, error) {
+	policy.DeviceType = strings.ToLower(strings.TrimSpace(policy.DeviceType))
+	policy.MinVersion = strings.TrimSpace(policy.MinVersion)
+	policy.RecommendedVersion = strings.TrimSpace(policy.RecommendedVersion)
+	policy.DownloadURL = strings.TrimSpace(policy.DownloadURL)
+
+	if policy.DeviceType == "" {
+		return model.ClientVersionPolicy{}, ErrDeviceTypeRequired
+	}
+	minVersion, minOK := parseVersion(policy.MinVersion)
+	recommended, recommendedOK := parseVersion(policy.RecommendedVersion)
+	if (policy.MinVersion != "" && !minOK) || (policy.RecommendedVersion != "" && !recommendedOK) {
+		return model.ClientVersionPolicy{}, ErrInvalidVersion
+	}
+	if minOK && recommendedOK && compareVersions(recommended, minVersion) < 0 {
+		return model.ClientVersionPolicy{}, ErrVersionOrder
+	}
+	if policy.DownloadURL != "" {
+		parsed, err := url.Parse(policy.DownloadURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return model.ClientVersionPolicy{}, ErrInvalidDownloadURL
+		}
+	}
+
+	return s.repo.Upsert(ctx, policy)
+}
+
+func (s *ClientVersionService) Delete(ctx context.Context, deviceType string) error {
+	return s.repo.Delete(ctx, strings.ToLower(strings.TrimSpace(deviceType)))
+}
+
+type version struct {
+	parts      [4]int
+	prerelease string
+}
+
+func parseVersion(raw string) (version, bool) {
+	raw = strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if raw == "" {
+		return version{}, false
+	}
+	if i := strings.IndexByte(raw, '+'); i >= 0 {
+		raw = raw[:i]
+	}
+
+	var v version
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		v.prerelease = raw[i+1:]
+		raw = raw[:i]
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) > len(v.parts) {
+		return version{}, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v.parts[i] = n
+	}
+	return v, true
+}
+
+func mustParseVersion(raw string) version {
+	v, _ := parseVersion(raw)
+	return v
+}
+
+func compareVersions(a, b version) int {
+	for i := range a.parts {
+		if a.parts[i] != b.parts[i] {
+			if a.parts[i] < b.parts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case a.prerelease == b.prerelease:
+		return 0
+	case a.prerelease == "":
+		return 1
+	case b.prerelease == "":
+		return -1
+	}
+
+	aFields := strings.Split(a.prerelease, ".")
+	bFields := strings.Split(b.prerelease, ".")
+	for i := 0; i < len(aFields) && i < len(bFields); i++ {
+		if c := comparePrereleaseField(aFields[i], bFields[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(aFields) < len(bFields):
+		return -1
+	case len(aFields) > len(bFields):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func comparePrereleaseField(a, b string) int {
+	aNum, aErr := strconv.ParseUint(a, 10, 64)
+	bNum, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		if aNum == bNum {
+			return 0
+		}
+		if aNum < bNum {
+			return -1
+		}
+		return 1
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		raw        string
+		parts      [4]int
+		prerelease string
+		ok         bool
+	}{
+		{raw: "1.2.3", parts: [4]int{1, 2, 3}, ok: true},
+		{raw: "v2.0", parts: [4]int{2}, ok: true},
+		{raw: " 1.2.3.4 ", parts: [4]int{1, 2, 3, 4}, ok: true},
+		{raw: "1.2.3-beta.1", parts: [4]int{1, 2, 3}, prerelease: "beta.1", ok: true},
+		{raw: "1.2.3+build.7", parts: [4]int{1, 2, 3}, ok: true},
+		{raw: "1.2.3-rc.1+build.7", parts: [4]int{1, 2, 3}, prerelease: "rc.1", ok: true},
+		{raw: ""},
+		{raw: "v"},
+		{raw: "1.2.3.4.5"},
+		{raw: "1.x"},
+		{raw: "1.-2"},
+	}
+	for _, tt := range tests {
+		v, ok := parseVersion(tt.raw)
+		if ok != tt.ok {
+			t.Errorf("parseVersion(%q) ok = %v, want %v", tt.raw, ok, tt.ok)
+			continue
+		}
+		if ok && (v.parts != tt.parts || v.prerelease != tt.prerelease) {
+			t.Errorf("parseVersion(%q) = %+v, want parts %v prerelease %q", tt.raw, v, tt.parts, tt.prerelease)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0.0", b: "1.0.0", want: 0},
+		{a: "1.0", b: "1.0.0.0", want: 0},
+		{a: "1.2.10", b: "1.2.9", want: 1},
+		{a: "1.10.0", b: "1.9.9", want: 1},
+		{a: "2.0.0", b: "10.0.0", want: -1},
+		{a: "1.0.0+build.1", b: "1.0.0+build.2", want: 0},
+		{a: "1.0.0-alpha", b: "1.0.0", want: -1},
+		{a: "1.0.0", b: "1.0.0-rc.1", want: 1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{a: "1.0.0-alpha.1", b: "1.0.0-alpha.beta", want: -1},
+		{a: "1.0.0-alpha.beta", b: "1.0.0-beta", want: -1},
+		{a: "1.0.0-beta", b: "1.0.0-beta.2", want: -1},
+		{a: "1.0.0-beta.2", b: "1.0.0-beta.11", want: -1},
+		{a: "1.0.0-beta.11", b: "1.0.0-rc.1", want: -1},
+		{a: "1.0.0-rc.10", b: "1.0.0-rc.9", want: 1},
+		{a: "1.0.0-rc.1", b: "1.0.0-rc.1", want: 0},
+	}
+	for _, tt := range tests {
+		a, aOK := parseVersion(tt.a)
+		b, bOK := parseVersion(tt.b)
+		if !aOK || !bOK {
+			t.Fatalf("invalid test versions %q, %q", tt.a, tt.b)
+		}
+		if got := compareVersions(a, b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareVersions(b, a); got != -tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"koalbot_api/internal/logging"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+)
+
+const (
+	defaultCommandTTL     = 10 * time.Minute
+	maxCommandTTL         = 24 * time.Hour
+	commandRedeliverAfter = 30 * time.Second
+	commandExpireEvery    = time.Minute
+)
+
+var (
+	ErrInvalidCommand   = errors.New("invalid_command")
+	ErrInvalidTTL       = errors.New("invalid_ttl")
+	ErrInvalidAckStatus = errors.New("invalid_ack_status")
+)
+
+type CommandService struct {
+	repo *repository.PenggunaCommandRepository
+
+	mu      sync.Mutex
+	waiters map[int64]map[chan struct{}]struct{}
+}
+
+func NewCommandService(repo *repository.PenggunaCommandRepository) *CommandService {
+	return &CommandService{
+		repo:    repo,
+		waiters: make(map[int64]map[chan struct{}]struct{}),
+	}
+}
+
+func IsValidCommand(command string) bool {
+	switch command {
+	case model.CommandStop, model.CommandPause, model.CommandResume, model.CommandReloadConfig:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *CommandService) Enqueue(ctx context.Context, penggunaID int64, command string, payload json.RawMessage, ttl time.Duration, createdBy string) (model.PenggunaCommand, error) {
+	if !IsValidCommand(command) {
+		return model.PenggunaCommand{}, ErrInvalidCommand
+	}
+	if ttl == 0 {
+		ttl = defaultCommandTTL
+	}
+	if ttl < 0 || ttl > maxCommandTTL {
+		return model.PenggunaCommand{}, ErrInvalidTTL
+	}
+
+	cmd, err := s.repo.Create(ctx, model.PenggunaCommand{
+		PenggunaID: penggunaID,
+		Command:    command,
+		Payload:    payload,
+		CreatedBy:  createdBy,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+	if err != nil {
+		return model.PenggunaCommand{}, err
+	}
+	s.notify(penggunaID)
+	return cmd, nil
+}
+
+func (s *CommandService) List(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaCommand, queryspec.Page, error) {
+	return s.repo.List(ctx, penggunaID, q)
+}
+
+func (s *CommandService) Cancel(ctx context.Context, penggunaID, id int64) error {
+	return s.repo.Cancel(ctx, penggunaID, id)
+}
+
+func (s *CommandService) Claim(ctx context.Context, penggunaID int64, deviceID string) ([]model.PenggunaCommand, error) {
+	return s.repo.Claim(ctx, penggunaID, deviceID, time.Now().Add(-commandRedeliverAfter))
+}
+
+func (s *CommandService) Wait(ctx context.Context, penggunaID int64, deviceID string, wait time.Duration) ([]model.PenggunaCommand, error) {
+	ch, release := s.subscribe(penggunaID)
+	defer release()
+
+	commands, err := s.Claim(ctx, penggunaID, deviceID)
+	if err != nil || len(commands) > 0 || wait <= 0 {
+		return commands, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return commands, nil
+	case <-timer.C:
+	case <-ch:
+	}
+	return s.Claim(ctx, penggunaID, deviceID)
+}
+
+func (s *CommandService) Subscribe(penggunaID int64) (<-chan struct{}, func()) {
+	return s.subscribe(penggunaID)
+}
+
+func (s *CommandService) Ack(ctx context.Context, penggunaID, id int64, status, result string) error {
+	if status != model.CommandAcked && status != model.CommandFailed {
+		return ErrInvalidAckStatus
+	}
+	return s.repo.Ack(ctx, penggunaID, id, status, result)
+}
+
+func (s *CommandService) Run(ctx context.Context) {
+	ticker := time.NewTicker(commandExpireEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.repo.ExpireDue(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logging.Errorf("command: expire failed: %v", err)
+				}
+				continue
+			}
+			if expired > 0 {
+				logging.Infof("command: expired %d commands", expired)
+			}
+		}
+	}
+}
+
+func (s *CommandService) subscribe(penggunaID int64) (chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	if s.waiters[penggunaID] == nil {
+		s.waiters[penggunaID] = make(map[chan struct{}]struct{})
+	}
+	s.waiters[penggunaID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.waiters[penggunaID], ch)
+		if len(s.waiters[penggunaID]) == 0 {
+			delete(s.waiters, penggunaID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *CommandService) notify(penggunaID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.waiters[penggunaID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
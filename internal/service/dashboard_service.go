@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+)
+
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+
+	maxDashboardRange = 366 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidBucket = errors.New("invalid_bucket")
+	ErrInvalidRange  = errors.New("invalid_range")
+)
+
+type DashboardService struct {
+	repo *repository.DashboardRepository
+}
+
+func NewDashboardService(repo *repository.DashboardRepository) *DashboardService {
+	return &DashboardService{repo: repo}
+}
+
+func (s *DashboardService) Summary(ctx context.Context) (model.DashboardSummary, error) {
+	return s.repo.Summary(ctx)
+}
+
+func (s *DashboardService) Breakdown(ctx context.Context, from, to time.Time) (model.DashboardBreakdown, error) {
+	if !to.After(from) {
+		return model.DashboardBreakdown{}, ErrInvalidRange
+	}
+	return s.repo.Breakdown(ctx, from, to)
+}
+
+func (s *DashboardService) Timeseries(ctx context.Context, from, to time.Time, bucket string) ([]model.DashboardPoint, error) {
+	if bucket != BucketDay && bucket != BucketWeek {
+		return nil, ErrInvalidBucket
+	}
+	if !to.After(from) || to.Sub(from) > maxDashboardRange {
+		return nil, ErrInvalidRange
+	}
+	return s.repo.Timeseries(ctx, from, to, bucket)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"koalbot_api/internal/logging"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+)
+
+const (
+	DevicePolicyReject = "reject"
+	DevicePolicyEvict  = "evict"
+)
+
+type deviceLimit struct {
+	max    int
+	policy string
+	idle   time.Duration
+}
+
+type DeviceService struct {
+	repo  *repository.PenggunaDeviceRepository
+	limit atomic.Pointer[deviceLimit]
+}
+
+func NewDeviceService(repo *repository.PenggunaDeviceRepository, max int, policy string, idle time.Duration) *DeviceService {
+	s := &DeviceService{repo: repo}
+	s.SetLimit(max, policy, idle)
+	return s
+}
+
+func (s *DeviceService) SetLimit(max int, policy string, idle time.Duration) {
+	s.limit.Store(&deviceLimit{max: max, policy: policy, idle: idle})
+}
+
+func (s *DeviceService) Limit() int {
+	return s.limit.Load().max
+}
+
+func (s *DeviceService) Bind(ctx context.Context, device model.PenggunaDevice) error {
+	limit := s.limit.Load()
+	evicted, err := s.repo.Bind(ctx, device, limit.max, limit.policy == DevicePolicyEvict, limit.idle)
+	if err != nil {
+		return err
+	}
+	for _, old := range evicted {
+		logging.Infof("device: evicted %s from pengguna %d for %s", old.DeviceID, device.PenggunaID, device.DeviceID)
+	}
+	return nil
+}
+
+func (s *DeviceService) IsBound(ctx context.Context, penggunaID int64, deviceID string) (bool, error) {
+	return s.repo.IsBound(ctx, penggunaID, deviceID)
+}
+
+func (s *DeviceService) List(ctx context.Context, penggunaID int64) ([]model.PenggunaDevice, error) {
+	return s.repo.List(ctx, penggunaID)
+}
+
+func (s *DeviceService) Unbind(ctx context.Context, penggunaID int64, deviceID string) error {
+	return s.repo.Unbind(ctx, penggunaID, deviceID)
+}
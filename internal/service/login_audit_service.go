@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"koalbot_api/internal/logging"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+)
+
+const AlertMultiDeviceLogin = "multi_device_login"
+
+type LoginAuditService struct {
+	repo            *repository.PenggunaLoginRepository
+	deviceThreshold int
+	deviceWindow    time.Duration
+}
+
+func NewLoginAuditService(repo *repository.PenggunaLoginRepository, deviceThreshold int, deviceWindow time.Duration) *LoginAuditService {
+	return &LoginAuditService{
+		repo:            repo,
+		deviceThreshold: deviceThreshold,
+		deviceWindow:    deviceWindow,
+	}
+}
+
+func (s *LoginAuditService) Record(ctx context.Context, login model.PenggunaLogin) error {
+	if err := s.repo.Record(ctx, login); err != nil {
+		return err
+	}
+	if login.Outcome != model.LoginSuccess || login.PenggunaID == nil || s.deviceThreshold <= 0 {
+		return nil
+	}
+
+	since := time.Now().Add(-s.deviceWindow)
+	devices, err := s.repo.CountDevices(ctx, *login.PenggunaID, since)
+	if err != nil {
+		return err
+	}
+	if devices < s.deviceThreshold {
+		return nil
+	}
+
+	created, err := s.repo.CreateAlertOnce(ctx, *login.PenggunaID, AlertMultiDeviceLogin, map[string]any{
+		"devices":      devices,
+		"window_hours": s.deviceWindow.Hours(),
+		"device_id":    login.DeviceID,
+		"device_type":  login.DeviceType,
+		"ip":           login.IP,
+	}, since)
+	if err != nil {
+		return err
+	}
+	if created {
+		logging.Warnf("alert: pengguna %d logged in from %d devices within %s", *login.PenggunaID, devices, s.deviceWindow)
+	}
+	return nil
+}
+
+func (s *LoginAuditService) List(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaLogin, queryspec.Page, error) {
+	return s.repo.List(ctx, penggunaID, q)
+}
+
+func (s *LoginAuditService) Alerts(ctx context.Context, q queryspec.Query) ([]model.PenggunaAlert, queryspec.Page, error) {
+	return s.repo.ListAlerts(ctx, q)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"koalbot_api/internal/repository"
+)
+
+const (
+	maxBulkIDs           = 1000
+	maxBulkFilterRows    = 5000
+	bulkConfirmThreshold = 100
+)
+
+var ErrBulkInvalidAction = errors.New("invalid_action")
+var ErrBulkNoTarget = errors.New("ids_or_filter_required")
+var ErrBulkTooManyIDs = errors.New("too_many_ids")
+var ErrBulkTooLarge = errors.New("bulk_too_large")
+var ErrBulkConfirmationRequired = errors.New("confirmation_required")
+
+func (s *MasterPenggunaService) Bulk(ctx context.Context, action string, ids []int64, filter *repository.BulkMasterPenggunaFilter, confirm int) ([]repository.BulkMasterPenggunaResult, int, error) {
+	if action != "activate" && action != "deactivate" && action != "delete" {
+		return nil, 0, ErrBulkInvalidAction
+	}
+	if (len(ids) == 0) == (filter == nil) {
+		return nil, 0, ErrBulkNoTarget
+	}
+
+	maxRows := len(ids)
+	if filter == nil {
+		if len(ids) > maxBulkIDs {
+			return nil, 0, ErrBulkTooManyIDs
+		}
+		ids = uniqueIDs(ids)
+	} else {
+		matched, err := s.repo.Count(ctx, filter.Search, filter.Jenis)
+		if err != nil {
+			return nil, 0, err
+		}
+		if matched > maxBulkFilterRows {
+			return nil, matched, ErrBulkTooLarge
+		}
+		if matched > bulkConfirmThreshold && confirm != matched {
+			return nil, matched, ErrBulkConfirmationRequired
+		}
+		maxRows = matched
+	}
+
+	results, err := s.repo.Bulk(ctx, action, ids, filter, maxRows)
+	if err != nil {
+		if errors.Is(err, repository.ErrBulkTooLarge) {
+			return nil, maxRows, ErrBulkConfirmationRequired
+		}
+		return nil, 0, err
+	}
+	return results, len(results), nil
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"koalbot_api/internal/repository"
+)
+
+const maxImportRows = 5000
+
+var ErrImportEmpty = errors.New("empty_file")
+var ErrImportMissingColumn = errors.New("missing_id_pengguna_column")
+var ErrImportTooManyRows = errors.New("too_many_rows")
+var ErrImportInvalidRows = errors.New("invalid_rows")
+
+type ImportRowResult struct {
+	Row        int      `json:"row"`
+	IDPengguna int64    `json:"id_pengguna,omitempty"`
+	Action     string   `json:"action,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Invalid int               `json:"invalid"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+func (s *MasterPenggunaService) Import(ctx context.Context, records [][]string, dryRun bool) (ImportReport, error) {
+	if len(records) < 2 {
+		return ImportReport{}, ErrImportEmpty
+	}
+	if len(records)-1 > maxImportRows {
+		return ImportReport{}, ErrImportTooManyRows
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["id_pengguna"]; !ok {
+		return ImportReport{}, ErrImportMissingColumn
+	}
+
+	report := ImportReport{DryRun: dryRun}
+	rows := make([]repository.ImportMasterPenggunaRow, 0, len(records)-1)
+	seen := map[int64]int{}
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		rowNum := i + 2
+		row, errs := parseImportRecord(record, columns)
+		if row.IDPengguna != 0 {
+			if first, dup := seen[row.IDPengguna]; dup {
+				errs = append(errs, "duplicate_of_row_"+strconv.Itoa(first))
+			} else {
+				seen[row.IDPengguna] = rowNum
+			}
+		}
+
+		report.Rows = append(report.Rows, ImportRowResult{Row: rowNum, IDPengguna: row.IDPengguna, Errors: errs})
+		if len(errs) > 0 {
+			report.Invalid++
+			continue
+		}
+		rows = append(rows, row)
+	}
+	report.Total = len(report.Rows)
+	if report.Total == 0 {
+		return ImportReport{}, ErrImportEmpty
+	}
+
+	ids := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.IDPengguna)
+	}
+	existing, err := s.repo.ExistingIDPengguna(ctx, ids)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	for i := range report.Rows {
+		result := &report.Rows[i]
+		if len(result.Errors) > 0 {
+			continue
+		}
+		if existing[result.IDPengguna] {
+			result.Action = "update"
+			report.Updated++
+		} else {
+			result.Action = "create"
+			report.Created++
+		}
+	}
+
+	if report.Invalid > 0 {
+		return report, ErrImportInvalidRows
+	}
+	if dryRun {
+		return report, nil
+	}
+	if err := s.repo.Import(ctx, rows); err != nil {
+		return ImportReport{}, err
+	}
+	return report, nil
+}
+
+func parseImportRecord(record []string, columns map[string]int) (repository.ImportMasterPenggunaRow, []string) {
+	var row repository.ImportMasterPenggunaRow
+	var errs []string
+
+	cell := func(name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	if raw := cell("id_pengguna"); raw == "" {
+		errs = append(errs, "id_pengguna_required")
+	} else if id, err := strconv.ParseInt(raw, 10, 64); err != nil || id <= 0 {
+		errs = append(errs, "invalid_id_pengguna")
+	} else {
+		row.IDPengguna = id
+	}
+
+	if raw := cell("telegram"); raw != "" {
+		if len(raw) > 100 {
+			errs = append(errs, "telegram_too_long")
+		} else {
+			row.Telegram = &raw
+		}
+	}
+
+	if raw := strings.ToLower(cell("jenis")); raw != "" {
+		if !IsValidJenis(raw) {
+			errs = append(errs, "invalid_jenis")
+		} else {
+			row.Jenis = &raw
+		}
+	}
+
+	if raw := cell("active"); raw != "" {
+		active, err := strconv.ParseBool(strings.ToLower(raw))
+		if err != nil {
+			errs = append(errs, "invalid_active")
+		} else {
+			row.Active = &active
+		}
+	}
+
+	return row, errs
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -3,13 +3,24 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
+	"koalbot_api/internal/logging"
 	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
 	"koalbot_api/internal/repository"
 )
 
 var ErrMasterPenggunaNoFields = errors.New("no_fields_to_update")
 
+const (
+	minSuggestLength = 2
+	maxSuggestLimit  = 20
+
+	penggunaExpireEvery = time.Minute
+)
+
 type MasterPenggunaService struct {
 	repo *repository.MasterPenggunaRepository
 }
@@ -26,21 +37,71 @@ func (s *MasterPenggunaService) GetByID(ctx context.Context, id int64) (model.Ma
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *MasterPenggunaService) List(ctx context.Context, search string, jenis string, limit, offset int) ([]model.MasterPengguna, int, error) {
-	return s.repo.List(ctx, search, jenis, limit, offset)
+func (s *MasterPenggunaService) GetByIDPengguna(ctx context.Context, idPengguna int64) (model.MasterPengguna, error) {
+	return s.repo.GetByIDPengguna(ctx, idPengguna)
+}
+
+func (s *MasterPenggunaService) List(ctx context.Context, q queryspec.Query, jenis string, deleted bool) ([]model.MasterPengguna, queryspec.Page, error) {
+	return s.repo.List(ctx, q, jenis, deleted)
+}
+
+func (s *MasterPenggunaService) Suggest(ctx context.Context, search string, limit int) ([]model.MasterPengguna, error) {
+	search = strings.TrimSpace(search)
+	if len([]rune(search)) < minSuggestLength {
+		return []model.MasterPengguna{}, nil
+	}
+	if limit < 1 || limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+	return s.repo.Suggest(ctx, search, limit)
 }
 
 func (s *MasterPenggunaService) Update(ctx context.Context, id int64, req repository.UpdateMasterPenggunaRequest) error {
-	if req.IDPengguna == nil && req.Telegram == nil && req.Jenis == nil && req.Active == nil {
+	if req.IDPengguna == nil && req.Telegram == nil && req.Jenis == nil && req.Active == nil && req.ActiveUntil == nil && !req.ClearActiveUntil {
 		return ErrMasterPenggunaNoFields
 	}
-	return s.repo.Update(ctx, id, req.IDPengguna, req.Telegram, req.Jenis, req.Active)
+	return s.repo.Update(ctx, id, req.IDPengguna, req.Telegram, req.Jenis, req.Active, req.ActiveUntil, req.ClearActiveUntil)
+}
+
+func (s *MasterPenggunaService) Export(ctx context.Context, search string, jenis string, fn func(model.MasterPengguna) error) error {
+	return s.repo.Export(ctx, search, jenis, fn)
 }
 
 func (s *MasterPenggunaService) Delete(ctx context.Context, id int64) error {
 	return s.repo.SoftDelete(ctx, id)
 }
 
+func (s *MasterPenggunaService) Restore(ctx context.Context, id int64) error {
+	return s.repo.Restore(ctx, id)
+}
+
 func (s *MasterPenggunaService) Summary(ctx context.Context) (int, int, error) {
 	return s.repo.CountByActive(ctx)
 }
+
+func (s *MasterPenggunaService) RunExpiry(ctx context.Context) {
+	ticker := time.NewTicker(penggunaExpireEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.repo.ExpireDue(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logging.Errorf("pengguna: expire failed: %v", err)
+				}
+				continue
+			}
+			if expired > 0 {
+				logging.Infof("pengguna: expired %d accounts", expired)
+			}
+		}
+	}
+}
+
+func IsValidJenis(val string) bool {
+	return val == "stockity" || val == "binomo" || val == "olymptrade"
+}
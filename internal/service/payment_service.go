@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"koalbot_api/internal/logging"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/payment"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+)
+
+var ErrUnknownProvider = errors.New("unknown_provider")
+
+type PaymentService struct {
+	repo       *repository.PaymentRepository
+	providers  map[string]payment.Provider
+	periodDays int
+}
+
+func NewPaymentService(repo *repository.PaymentRepository, periodDays int, providers ...payment.Provider) *PaymentService {
+	s := &PaymentService{repo: repo, providers: make(map[string]payment.Provider, len(providers)), periodDays: periodDays}
+	for _, provider := range providers {
+		s.providers[provider.Name()] = provider
+	}
+	return s
+}
+
+func (s *PaymentService) Receive(ctx context.Context, providerName string, header http.Header, body []byte) (model.Payment, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return model.Payment{}, "", ErrUnknownProvider
+	}
+
+	notification, err := provider.Parse(header, body)
+	if err != nil {
+		return model.Payment{}, "", err
+	}
+	if !notification.Paid {
+		return model.Payment{}, model.PaymentIgnored, nil
+	}
+
+	applied, created, err := s.repo.Apply(ctx, model.Payment{
+		Provider:          provider.Name(),
+		ProviderPaymentID: notification.PaymentID,
+		Reference:         notification.Reference,
+		IDPengguna:        notification.IDPengguna,
+		Amount:            notification.Amount,
+		Currency:          notification.Currency,
+		PeriodDays:        s.periodDays,
+		PaidAt:            notification.PaidAt,
+		Payload:           body,
+	})
+	if err != nil {
+		return model.Payment{}, "", err
+	}
+	if !created {
+		logging.Warnf("payment: rejected replay of %s payment %s", applied.Provider, applied.ProviderPaymentID)
+		return applied, model.PaymentDuplicate, nil
+	}
+	logging.Infof("payment: %s payment %s extended id_pengguna %d by %d days", applied.Provider, applied.ProviderPaymentID, applied.IDPengguna, applied.PeriodDays)
+	return applied, model.PaymentApplied, nil
+}
+
+func (s *PaymentService) List(ctx context.Context, q queryspec.Query) ([]model.Payment, queryspec.Page, error) {
+	return s.repo.List(ctx, q)
+}
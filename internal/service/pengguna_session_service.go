@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+)
+
+var (
+	ErrSessionNotFound      = errors.New("account_not_found")
+	ErrSessionInactive      = errors.New("account_inactive")
+	ErrSessionDeviceUnbound = errors.New("device_unbound")
+)
+
+type PenggunaSessionService struct {
+	master  *repository.MasterPenggunaRepository
+	devices *repository.PenggunaDeviceRepository
+}
+
+func NewPenggunaSessionService(master *repository.MasterPenggunaRepository, devices *repository.PenggunaDeviceRepository) *PenggunaSessionService {
+	return &PenggunaSessionService{master: master, devices: devices}
+}
+
+func (s *PenggunaSessionService) VerifySession(ctx context.Context, uuid, deviceID string) (model.MasterPengguna, error) {
+	master, err := s.master.GetByUUID(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.MasterPengguna{}, ErrSessionNotFound
+		}
+		return model.MasterPengguna{}, err
+	}
+	if !master.Active {
+		return model.MasterPengguna{}, ErrSessionInactive
+	}
+
+	bound, err := s.devices.IsBound(ctx, master.ID, deviceID)
+	if err != nil {
+		return model.MasterPengguna{}, err
+	}
+	if !bound {
+		return model.MasterPengguna{}, ErrSessionDeviceUnbound
+	}
+	return master, nil
+}
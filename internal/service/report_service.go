@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+)
+
+const maxReportRange = 366 * 24 * time.Hour
+
+var ErrInvalidAccountType = errors.New("invalid_account_type")
+
+type ReportService struct {
+	repo *repository.ReportRepository
+}
+
+func NewReportService(repo *repository.ReportRepository) *ReportService {
+	return &ReportService{repo: repo}
+}
+
+func (s *ReportService) Performance(ctx context.Context, penggunaID int64, accountType string, from, to time.Time, period string) (model.PerformanceReport, error) {
+	if err := validateReport(accountType, from, to); err != nil {
+		return model.PerformanceReport{}, err
+	}
+	if period != BucketDay && period != BucketWeek && period != BucketMonth {
+		return model.PerformanceReport{}, ErrInvalidBucket
+	}
+
+	report := model.PerformanceReport{
+		PenggunaID:  penggunaID,
+		AccountType: accountType,
+		From:        from,
+		To:          to,
+		Period:      period,
+	}
+
+	if accountType == "real" {
+		change, err := s.repo.BalanceChange(ctx, penggunaID, from, to)
+		switch {
+		case errors.Is(err, sql.ErrNoRows) && penggunaID == 0:
+		case err != nil:
+			return model.PerformanceReport{}, err
+		case change.Accounts > 0:
+			report.Balance = &change
+		}
+	}
+
+	buckets := make(map[time.Time]*model.PerformancePeriod)
+	for bucket := truncateBucket(from, period); bucket.Before(to); bucket = nextBucket(bucket, period) {
+		report.Periods = append(report.Periods, model.PerformancePeriod{Bucket: bucket})
+	}
+	for i := range report.Periods {
+		buckets[report.Periods[i].Bucket] = &report.Periods[i]
+	}
+
+	var total performanceTracker
+	err := s.repo.ClosedTrades(ctx, penggunaID, accountType, from, to, func(outcome model.TradeOutcome) error {
+		total.add(outcome)
+		if p := buckets[truncateBucket(outcome.ClosedAt, period)]; p != nil {
+			p.Trades++
+			p.Volume += outcome.Amount
+			p.Profit += outcome.Payout - outcome.Amount
+			switch outcome.Result {
+			case model.TradeWin:
+				p.Wins++
+			case model.TradeLoss:
+				p.Losses++
+			default:
+				p.Draws++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return model.PerformanceReport{}, err
+	}
+
+	report.Summary = total.result()
+	for i := range report.Periods {
+		p := &report.Periods[i]
+		p.WinRate = winRate(p.Wins, p.Losses)
+		p.Volume = roundMoney(p.Volume)
+		p.Profit = roundMoney(p.Profit)
+	}
+	return report, nil
+}
+
+func (s *ReportService) Traders(ctx context.Context, accountType string, from, to time.Time) ([]model.TraderPerformance, error) {
+	if err := validateReport(accountType, from, to); err != nil {
+		return nil, err
+	}
+
+	trackers := make(map[int64]*performanceTracker)
+	idPengguna := make(map[int64]int64)
+	err := s.repo.ClosedTrades(ctx, 0, accountType, from, to, func(outcome model.TradeOutcome) error {
+		tracker := trackers[outcome.PenggunaID]
+		if tracker == nil {
+			tracker = &performanceTracker{}
+			trackers[outcome.PenggunaID] = tracker
+			idPengguna[outcome.PenggunaID] = outcome.IDPengguna
+		}
+		tracker.add(outcome)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	traders := make([]model.TraderPerformance, 0, len(trackers))
+	for penggunaID, tracker := range trackers {
+		traders = append(traders, model.TraderPerformance{
+			PenggunaID:       penggunaID,
+			IDPengguna:       idPengguna[penggunaID],
+			PerformanceStats: tracker.result(),
+		})
+	}
+	sort.Slice(traders, func(i, j int) bool {
+		if traders[i].Profit != traders[j].Profit {
+			return traders[i].Profit > traders[j].Profit
+		}
+		return traders[i].PenggunaID < traders[j].PenggunaID
+	})
+	return traders, nil
+}
+
+func validateReport(accountType string, from, to time.Time) error {
+	if accountType != "demo" && accountType != "real" {
+		return ErrInvalidAccountType
+	}
+	if !to.After(from) || to.Sub(from) > maxReportRange {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+type performanceTracker struct {
+	stats  model.PerformanceStats
+	equity float64
+	peak   float64
+	streak int
+}
+
+func (t *performanceTracker) add(outcome model.TradeOutcome) {
+	t.stats.Trades++
+	t.stats.Volume += outcome.Amount
+
+	t.equity += outcome.Payout - outcome.Amount
+	if t.equity > t.peak {
+		t.peak = t.equity
+	}
+	if drawdown := t.peak - t.equity; drawdown > t.stats.MaxDrawdown {
+		t.stats.MaxDrawdown = drawdown
+	}
+
+	switch outcome.Result {
+	case model.TradeWin:
+		t.stats.Wins++
+		if t.streak < 0 {
+			t.streak = 0
+		}
+		t.streak++
+		if t.streak > t.stats.MaxWinStreak {
+			t.stats.MaxWinStreak = t.streak
+		}
+	case model.TradeLoss:
+		t.stats.Losses++
+		if t.streak > 0 {
+			t.streak = 0
+		}
+		t.streak--
+		if -t.streak > t.stats.MaxLossStreak {
+			t.stats.MaxLossStreak = -t.streak
+		}
+	default:
+		t.stats.Draws++
+	}
+}
+
+func (t *performanceTracker) result() model.PerformanceStats {
+	stats := t.stats
+	stats.Profit = roundMoney(t.equity)
+	stats.Volume = roundMoney(stats.Volume)
+	stats.MaxDrawdown = roundMoney(stats.MaxDrawdown)
+	stats.WinRate = winRate(stats.Wins, stats.Losses)
+	stats.CurrentStreak = t.streak
+	return stats
+}
+
+func winRate(wins, losses int) float64 {
+	if wins+losses == 0 {
+		return 0
+	}
+	return math.Round(float64(wins)/float64(wins+losses)*10000) / 100
+}
+
+func roundMoney(val float64) float64 {
+	return math.Round(val*100) / 100
+}
+
+func truncateBucket(t time.Time, period string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case BucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextBucket(t time.Time, period string) time.Time {
+	switch period {
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
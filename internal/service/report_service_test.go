@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"koalbot_api/internal/model"
+)
+
+func TestPerformanceTracker(t *testing.T) {
+	win := model.TradeOutcome{Amount: 10, Payout: 18.5, Result: model.TradeWin}
+	loss := model.TradeOutcome{Amount: 10, Result: model.TradeLoss}
+	draw := model.TradeOutcome{Amount: 10, Payout: 10, Result: model.TradeDraw}
+
+	tests := []struct {
+		name     string
+		outcomes []model.TradeOutcome
+		want     model.PerformanceStats
+	}{
+		{name: "empty"},
+		{
+			name:     "win streak",
+			outcomes: []model.TradeOutcome{win, win, win},
+			want:     model.PerformanceStats{Trades: 3, Wins: 3, WinRate: 100, Volume: 30, Profit: 25.5, MaxWinStreak: 3, CurrentStreak: 3},
+		},
+		{
+			name:     "loss streak",
+			outcomes: []model.TradeOutcome{loss, loss},
+			want:     model.PerformanceStats{Trades: 2, Losses: 2, Volume: 20, Profit: -20, MaxDrawdown: 20, MaxLossStreak: 2, CurrentStreak: -2},
+		},
+		{
+			name:     "streak resets on opposite result",
+			outcomes: []model.TradeOutcome{win, win, loss, win},
+			want: model.PerformanceStats{
+				Trades: 4, Wins: 3, Losses: 1, WinRate: 75, Volume: 40, Profit: 15.5,
+				MaxDrawdown: 10, MaxWinStreak: 2, MaxLossStreak: 1, CurrentStreak: 1,
+			},
+		},
+		{
+			name:     "draw keeps streak",
+			outcomes: []model.TradeOutcome{loss, draw, loss},
+			want: model.PerformanceStats{
+				Trades: 3, Losses: 2, Draws: 1, Volume: 30, Profit: -20,
+				MaxDrawdown: 20, MaxLossStreak: 2, CurrentStreak: -2,
+			},
+		},
+		{
+			name:     "drawdown measured from peak",
+			outcomes: []model.TradeOutcome{win, win, loss, loss, loss, win},
+			want: model.PerformanceStats{
+				Trades: 6, Wins: 3, Losses: 3, WinRate: 50, Volume: 60, Profit: -4.5,
+				MaxDrawdown: 30, MaxWinStreak: 2, MaxLossStreak: 3, CurrentStreak: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tracker performanceTracker
+			for _, outcome := range tt.outcomes {
+				tracker.add(outcome)
+			}
+			if got := tracker.result(); got != tt.want {
+				t.Errorf("result() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
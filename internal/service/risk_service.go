@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"koalbot_api/internal/logging"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/repository"
+)
+
+const AlertRiskHalt = "risk_halt"
+
+var (
+	ErrRiskPlanNameRequired = errors.New("name_required")
+	ErrRiskPlanNotFound     = errors.New("risk_plan_not_found")
+	ErrInvalidDailyLoss     = errors.New("invalid_max_daily_loss")
+	ErrInvalidTradesPerHour = errors.New("invalid_max_trades_per_hour")
+	ErrInvalidStakePct      = errors.New("invalid_max_stake_pct")
+	ErrInvalidTradingHours  = errors.New("invalid_trading_hours")
+	ErrInvalidTimezone      = errors.New("invalid_timezone")
+)
+
+type RiskService struct {
+	repo   *repository.RiskRepository
+	alerts *repository.PenggunaLoginRepository
+}
+
+func NewRiskService(repo *repository.RiskRepository, alerts *repository.PenggunaLoginRepository) *RiskService {
+	return &RiskService{repo: repo, alerts: alerts}
+}
+
+func EffectiveRiskRules(risk model.PenggunaRisk) model.RiskRules {
+	rules := risk.Plan
+	if risk.Overrides.MaxDailyLoss != nil {
+		rules.MaxDailyLoss = risk.Overrides.MaxDailyLoss
+	}
+	if risk.Overrides.MaxTradesPerHour != nil {
+		rules.MaxTradesPerHour = risk.Overrides.MaxTradesPerHour
+	}
+	if risk.Overrides.MaxStakePct != nil {
+		rules.MaxStakePct = risk.Overrides.MaxStakePct
+	}
+	if risk.Overrides.TradingStart != nil {
+		rules.TradingStart = risk.Overrides.TradingStart
+		rules.TradingEnd = risk.Overrides.TradingEnd
+	}
+	if risk.Overrides.Timezone != nil {
+		rules.Timezone = risk.Overrides.Timezone
+	}
+	return rules
+}
+
+func (s *RiskService) ListPlans(ctx context.Context) ([]model.RiskPlan, error) {
+	return s.repo.ListPlans(ctx)
+}
+
+func (s *RiskService) GetPlan(ctx context.Context, id int64) (model.RiskPlan, error) {
+	return s.repo.GetPlan(ctx, id)
+}
+
+func (s *RiskService) CreatePlan(ctx context.Context, name string, rules model.RiskRules, createdBy string) (model.RiskPlan, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.RiskPlan{}, ErrRiskPlanNameRequired
+	}
+	if err := validateRiskRules(&rules); err != nil {
+		return model.RiskPlan{}, err
+	}
+	return s.repo.CreatePlan(ctx, model.RiskPlan{Name: name, Rules: rules, CreatedBy: createdBy})
+}
+
+func (s *RiskService) UpdatePlan(ctx context.Context, id int64, name string, rules model.RiskRules, updatedBy string) (model.RiskPlan, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.RiskPlan{}, ErrRiskPlanNameRequired
+	}
+	if err := validateRiskRules(&rules); err != nil {
+		return model.RiskPlan{}, err
+	}
+	return s.repo.UpdatePlan(ctx, model.RiskPlan{ID: id, Name: name, Rules: rules, UpdatedBy: updatedBy})
+}
+
+func (s *RiskService) DeletePlan(ctx context.Context, id int64) error {
+	return s.repo.DeletePlan(ctx, id)
+}
+
+func (s *RiskService) Get(ctx context.Context, penggunaID int64) (model.PenggunaRisk, error) {
+	return s.repo.Get(ctx, penggunaID)
+}
+
+func (s *RiskService) Set(ctx context.Context, penggunaID int64, planID *int64, overrides model.RiskRules, updatedBy string) (model.PenggunaRisk, error) {
+	if err := validateRiskRules(&overrides); err != nil {
+		return model.PenggunaRisk{}, err
+	}
+	if planID != nil {
+		if _, err := s.repo.GetPlan(ctx, *planID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return model.PenggunaRisk{}, ErrRiskPlanNotFound
+			}
+			return model.PenggunaRisk{}, err
+		}
+	}
+	if err := s.repo.Save(ctx, penggunaID, planID, overrides, updatedBy); err != nil {
+		return model.PenggunaRisk{}, err
+	}
+	return s.repo.Get(ctx, penggunaID)
+}
+
+func (s *RiskService) Halt(ctx context.Context, penggunaID int64, rule, reason string) (bool, error) {
+	halted, err := s.repo.Halt(ctx, penggunaID, rule, reason)
+	if err != nil || !halted {
+		return halted, err
+	}
+
+	logging.Warnf("risk: pengguna %d halted by %s: %s", penggunaID, rule, reason)
+	if _, err := s.alerts.CreateAlertOnce(ctx, penggunaID, AlertRiskHalt, map[string]any{
+		"rule":   rule,
+		"reason": reason,
+	}, time.Now()); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (s *RiskService) Resume(ctx context.Context, penggunaID int64, updatedBy string) (bool, error) {
+	if _, err := s.repo.Get(ctx, penggunaID); err != nil {
+		return false, err
+	}
+	return s.repo.Resume(ctx, penggunaID, updatedBy)
+}
+
+func (s *RiskService) Evaluate(ctx context.Context, penggunaID int64, trades []model.PenggunaTrade) (bool, error) {
+	risk, err := s.repo.Get(ctx, penggunaID)
+	if err != nil {
+		return false, err
+	}
+	if risk.Halted {
+		return true, nil
+	}
+
+	rules := EffectiveRiskRules(risk)
+	if rules.MaxDailyLoss == nil && rules.MaxTradesPerHour == nil && rules.MaxStakePct == nil && rules.TradingStart == nil {
+		return false, nil
+	}
+
+	loc := time.UTC
+	if rules.Timezone != nil {
+		if loaded, err := time.LoadLocation(*rules.Timezone); err == nil {
+			loc = loaded
+		}
+	}
+
+	now := time.Now().In(loc)
+	exposure, err := s.repo.Exposure(ctx, penggunaID, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc), now.Add(-time.Hour))
+	if err != nil {
+		return false, err
+	}
+
+	rule, reason := checkRiskRules(rules, loc, exposure, trades)
+	if rule == "" {
+		return false, nil
+	}
+	if _, err := s.Halt(ctx, penggunaID, rule, reason); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func checkRiskRules(rules model.RiskRules, loc *time.Location, exposure model.RiskExposure, trades []model.PenggunaTrade) (string, string) {
+	if rules.TradingStart != nil && rules.TradingEnd != nil {
+		for _, trade := range trades {
+			if !withinTradingHours(trade.OpenedAt.In(loc), *rules.TradingStart, *rules.TradingEnd) {
+				return model.RiskRuleTradingHours, fmt.Sprintf("trade %s opened at %s outside %s-%s %s",
+					trade.ClientTradeID, trade.OpenedAt.In(loc).Format("15:04"), *rules.TradingStart, *rules.TradingEnd, loc)
+			}
+		}
+	}
+	if rules.MaxStakePct != nil && exposure.Balance != nil && *exposure.Balance > 0 {
+		for _, trade := range trades {
+			if trade.AccountType != "real" {
+				continue
+			}
+			if pct := trade.Amount / *exposure.Balance * 100; pct > *rules.MaxStakePct {
+				return model.RiskRuleStakePct, fmt.Sprintf("trade %s staked %.2f%% of balance, limit %.2f%%",
+					trade.ClientTradeID, pct, *rules.MaxStakePct)
+			}
+		}
+	}
+	if rules.MaxDailyLoss != nil && -exposure.DailyProfit >= *rules.MaxDailyLoss {
+		return model.RiskRuleDailyLoss, fmt.Sprintf("daily loss %.2f reached limit %.2f", -exposure.DailyProfit, *rules.MaxDailyLoss)
+	}
+	if rules.MaxTradesPerHour != nil && exposure.HourTrades > *rules.MaxTradesPerHour {
+		return model.RiskRuleTradesPerHour, fmt.Sprintf("%d trades in the last hour, limit %d", exposure.HourTrades, *rules.MaxTradesPerHour)
+	}
+	return "", ""
+}
+
+func withinTradingHours(t time.Time, start, end string) bool {
+	current := t.Format("15:04")
+	if start < end {
+		return current >= start && current < end
+	}
+	return current >= start || current < end
+}
+
+func validateRiskRules(rules *model.RiskRules) error {
+	if rules.MaxDailyLoss != nil && *rules.MaxDailyLoss <= 0 {
+		return ErrInvalidDailyLoss
+	}
+	if rules.MaxTradesPerHour != nil && *rules.MaxTradesPerHour <= 0 {
+		return ErrInvalidTradesPerHour
+	}
+	if rules.MaxStakePct != nil && (*rules.MaxStakePct <= 0 || *rules.MaxStakePct > 100) {
+		return ErrInvalidStakePct
+	}
+	if (rules.TradingStart == nil) != (rules.TradingEnd == nil) {
+		return ErrInvalidTradingHours
+	}
+	if rules.TradingStart != nil {
+		start, startErr := time.Parse("15:04", strings.TrimSpace(*rules.TradingStart))
+		end, endErr := time.Parse("15:04", strings.TrimSpace(*rules.TradingEnd))
+		if startErr != nil || endErr != nil || start.Equal(end) {
+			return ErrInvalidTradingHours
+		}
+		startVal, endVal := start.Format("15:04"), end.Format("15:04")
+		rules.TradingStart, rules.TradingEnd = &startVal, &endVal
+	}
+	if rules.Timezone != nil {
+		if _, err := time.LoadLocation(*rules.Timezone); err != nil || *rules.Timezone == "" || *rules.Timezone == "Local" {
+			return ErrInvalidTimezone
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"koalbot_api/internal/model"
+)
+
+func TestWithinTradingHours(t *testing.T) {
+	tests := []struct {
+		at         string
+		start, end string
+		want       bool
+	}{
+		{at: "09:00", start: "09:00", end: "17:00", want: true},
+		{at: "16:59", start: "09:00", end: "17:00", want: true},
+		{at: "17:00", start: "09:00", end: "17:00"},
+		{at: "08:59", start: "09:00", end: "17:00"},
+		{at: "22:00", start: "22:00", end: "06:00", want: true},
+		{at: "23:59", start: "22:00", end: "06:00", want: true},
+		{at: "00:00", start: "22:00", end: "06:00", want: true},
+		{at: "05:59", start: "22:00", end: "06:00", want: true},
+		{at: "06:00", start: "22:00", end: "06:00"},
+		{at: "12:00", start: "22:00", end: "06:00"},
+		{at: "21:59", start: "22:00", end: "06:00"},
+	}
+	for _, tt := range tests {
+		at, err := time.Parse("15:04", tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := withinTradingHours(at, tt.start, tt.end); got != tt.want {
+			t.Errorf("withinTradingHours(%s, %s-%s) = %v, want %v", tt.at, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRiskRules(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	float := func(v float64) *float64 { return &v }
+	integer := func(v int) *int { return &v }
+	text := func(v string) *string { return &v }
+
+	// 16:30 UTC is 23:30 in Jakarta, inside a window that crosses midnight.
+	night := model.PenggunaTrade{ClientTradeID: "t1", Amount: 10, AccountType: "real", OpenedAt: time.Date(2026, 5, 1, 16, 30, 0, 0, time.UTC)}
+	noon := model.PenggunaTrade{ClientTradeID: "t2", Amount: 10, AccountType: "real", OpenedAt: time.Date(2026, 5, 1, 5, 0, 0, 0, time.UTC)}
+	demo := model.PenggunaTrade{ClientTradeID: "t3", Amount: 500, AccountType: "demo", OpenedAt: night.OpenedAt}
+
+	tests := []struct {
+		name     string
+		rules    model.RiskRules
+		exposure model.RiskExposure
+		trades   []model.PenggunaTrade
+		want     string
+	}{
+		{name: "no rules", trades: []model.PenggunaTrade{night}},
+		{
+			name:   "inside overnight window",
+			rules:  model.RiskRules{TradingStart: text("22:00"), TradingEnd: text("02:00")},
+			trades: []model.PenggunaTrade{night},
+		},
+		{
+			name:   "outside overnight window",
+			rules:  model.RiskRules{TradingStart: text("22:00"), TradingEnd: text("02:00")},
+			trades: []model.PenggunaTrade{night, noon},
+			want:   model.RiskRuleTradingHours,
+		},
+		{
+			name:     "stake within limit",
+			rules:    model.RiskRules{MaxStakePct: float(5)},
+			exposure: model.RiskExposure{Balance: float(200)},
+			trades:   []model.PenggunaTrade{night},
+		},
+		{
+			name:     "stake over limit",
+			rules:    model.RiskRules{MaxStakePct: float(4)},
+			exposure: model.RiskExposure{Balance: float(200)},
+			trades:   []model.PenggunaTrade{night},
+			want:     model.RiskRuleStakePct,
+		},
+		{
+			name:     "demo stake ignored",
+			rules:    model.RiskRules{MaxStakePct: float(5)},
+			exposure: model.RiskExposure{Balance: float(200)},
+			trades:   []model.PenggunaTrade{demo},
+		},
+		{
+			name:   "stake without balance",
+			rules:  model.RiskRules{MaxStakePct: float(1)},
+			trades: []model.PenggunaTrade{night},
+		},
+		{
+			name:     "daily loss below limit",
+			rules:    model.RiskRules{MaxDailyLoss: float(100)},
+			exposure: model.RiskExposure{DailyProfit: -99.99},
+		},
+		{
+			name:     "daily loss reached",
+			rules:    model.RiskRules{MaxDailyLoss: float(100)},
+			exposure: model.RiskExposure{DailyProfit: -100},
+			want:     model.RiskRuleDailyLoss,
+		},
+		{
+			name:     "trades per hour at limit",
+			rules:    model.RiskRules{MaxTradesPerHour: integer(10)},
+			exposure: model.RiskExposure{HourTrades: 10},
+		},
+		{
+			name:     "trades per hour over limit",
+			rules:    model.RiskRules{MaxTradesPerHour: integer(10)},
+			exposure: model.RiskExposure{HourTrades: 11},
+			want:     model.RiskRuleTradesPerHour,
+		},
+		{
+			name:     "trading hours checked first",
+			rules:    model.RiskRules{TradingStart: text("09:00"), TradingEnd: text("10:00"), MaxDailyLoss: float(1)},
+			exposure: model.RiskExposure{DailyProfit: -5},
+			trades:   []model.PenggunaTrade{night},
+			want:     model.RiskRuleTradingHours,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, reason := checkRiskRules(tt.rules, jakarta, tt.exposure, tt.trades)
+			if rule != tt.want {
+				t.Errorf("checkRiskRules() = %q (%s), want %q", rule, reason, tt.want)
+			}
+			if (rule == "") != (reason == "") {
+				t.Errorf("rule %q with reason %q", rule, reason)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,6 +12,11 @@ import (
 	"koalbot_api/internal/repository"
 )
 
+var (
+	ErrTokenRevoked = errors.New("token_revoked")
+	ErrUserDisabled = errors.New("user_disabled")
+)
+
 const (
 	accessTokenTTL  = 24 * time.Hour
 	refreshTokenTTL = 7 * 24 * time.Hour
@@ -24,6 +31,7 @@ type tokenClaims struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
 	LastSeen int64  `json:"last_seen"`
+	Version  int    `json:"tv"`
 	jwt.RegisteredClaims
 }
 
@@ -55,11 +63,33 @@ func (s *TokenService) IssueTokens(ctx context.Context, user model.User, lastSee
 	return accessToken, refreshToken, accessExp, refreshExp, nil
 }
 
+func (s *TokenService) RevokeUser(ctx context.Context, userUID string) (int64, error) {
+	return s.repo.RevokeUser(ctx, userUID)
+}
+
+func (s *TokenService) VerifyUser(ctx context.Context, userUID string, version int) (string, error) {
+	active, current, role, err := s.repo.UserState(ctx, userUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrUserDisabled
+		}
+		return "", err
+	}
+	if !active {
+		return "", ErrUserDisabled
+	}
+	if version != current {
+		return "", ErrTokenRevoked
+	}
+	return role, nil
+}
+
 func (s *TokenService) signToken(user model.User, lastSeen time.Time, expiresAt time.Time) (string, error) {
 	claims := tokenClaims{
 		Username: user.Username,
 		Role:     user.Role,
 		LastSeen: lastSeen.Unix(),
+		Version:  user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   user.UID,
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
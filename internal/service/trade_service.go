@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+)
+
+const (
+	maxTradeBatch     = 500
+	maxTradeIDLength  = 64
+	maxTradeAssetLen  = 50
+	maxTradeBackdate  = 366 * 24 * time.Hour
+	maxTradeClockSkew = 24 * time.Hour
+)
+
+var (
+	ErrTradeBatchEmpty    = errors.New("trades_required")
+	ErrTradeBatchTooLarge = errors.New("too_many_trades")
+)
+
+type TradeResult struct {
+	ClientTradeID string
+	Status        string
+	Error         string
+}
+
+type TradeService struct {
+	repo *repository.PenggunaTradeRepository
+	risk *RiskService
+}
+
+func NewTradeService(repo *repository.PenggunaTradeRepository, risk *RiskService) *TradeService {
+	return &TradeService{repo: repo, risk: risk}
+}
+
+func (s *TradeService) Ingest(ctx context.Context, penggunaID int64, deviceID string, trades []model.PenggunaTrade) ([]TradeResult, bool, error) {
+	if len(trades) == 0 {
+		return nil, false, ErrTradeBatchEmpty
+	}
+	if len(trades) > maxTradeBatch {
+		return nil, false, ErrTradeBatchTooLarge
+	}
+
+	now := time.Now()
+	results := make([]TradeResult, len(trades))
+	valid := make([]model.PenggunaTrade, 0, len(trades))
+	positions := make([]int, 0, len(trades))
+	for i, trade := range trades {
+		trade.PenggunaID = penggunaID
+		trade.DeviceID = deviceID
+		trade.ClientTradeID = strings.TrimSpace(trade.ClientTradeID)
+		trade.Asset = strings.TrimSpace(trade.Asset)
+		if trade.AccountType == "" {
+			trade.AccountType = "real"
+		}
+
+		results[i].ClientTradeID = trade.ClientTradeID
+		if problem := validateTrade(trade, now); problem != "" {
+			results[i].Status = model.TradeRejected
+			results[i].Error = problem
+			continue
+		}
+		valid = append(valid, trade)
+		positions = append(positions, i)
+	}
+
+	if len(valid) == 0 {
+		return results, false, nil
+	}
+	statuses, err := s.repo.Ingest(ctx, valid)
+	if err != nil {
+		return nil, false, err
+	}
+	for i, status := range statuses {
+		results[positions[i]].Status = status
+	}
+
+	halted, err := s.risk.Evaluate(ctx, penggunaID, valid)
+	if err != nil {
+		return nil, false, err
+	}
+	return results, halted, nil
+}
+
+func (s *TradeService) List(ctx context.Context, penggunaID int64, q queryspec.Query) ([]model.PenggunaTrade, queryspec.Page, error) {
+	return s.repo.List(ctx, penggunaID, q)
+}
+
+func validateTrade(trade model.PenggunaTrade, now time.Time) string {
+	switch {
+	case trade.ClientTradeID == "":
+		return "client_trade_id_required"
+	case len(trade.ClientTradeID) > maxTradeIDLength:
+		return "client_trade_id_too_long"
+	case trade.Asset == "":
+		return "asset_required"
+	case len(trade.Asset) > maxTradeAssetLen:
+		return "asset_too_long"
+	case trade.Direction != model.TradeCall && trade.Direction != model.TradePut:
+		return "invalid_direction"
+	case trade.Amount <= 0:
+		return "invalid_amount"
+	case trade.AccountType != "demo" && trade.AccountType != "real":
+		return "invalid_account_type"
+	case trade.OpenedAt.IsZero() || trade.OpenedAt.Before(now.Add(-maxTradeBackdate)) || trade.OpenedAt.After(now.Add(maxTradeClockSkew)):
+		return "invalid_open_time"
+	}
+
+	if trade.ClosedAt == nil {
+		if trade.Result != "" || trade.Payout != nil {
+			return "close_time_required"
+		}
+		return ""
+	}
+	switch {
+	case trade.ClosedAt.Before(trade.OpenedAt):
+		return "invalid_close_time"
+	case trade.Result != model.TradeWin && trade.Result != model.TradeLoss && trade.Result != model.TradeDraw:
+		return "invalid_result"
+	case trade.Payout == nil || *trade.Payout < 0:
+		return "invalid_payout"
+	}
+	return ""
+}
@@ -2,16 +2,19 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
 	"koalbot_api/internal/repository"
 )
 
 var ErrInvalidRole = errors.New("invalid_role")
 var ErrNoFieldsToUpdate = errors.New("no_fields_to_update")
+var ErrUserNotFound = errors.New("user_not_found")
 
 type UserService struct {
 	users *repository.UserRepository
@@ -63,8 +66,29 @@ func (s *UserService) Delete(ctx context.Context, uid, deletedBy string) error {
 	return s.users.SoftDeleteUser(ctx, uid, deletedBy)
 }
 
-func (s *UserService) List(ctx context.Context, search string, limit, offset int) ([]model.User, int, error) {
-	return s.users.ListUsers(ctx, search, limit, offset)
+func (s *UserService) Restore(ctx context.Context, uid, restoredBy string) error {
+	if err := s.users.RestoreUser(ctx, uid, restoredBy); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *UserService) List(ctx context.Context, q queryspec.Query, deleted bool) ([]model.User, queryspec.Page, error) {
+	return s.users.ListUsers(ctx, q, deleted)
+}
+
+func (s *UserService) GetByUsername(ctx context.Context, username string) (model.User, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return user, nil
 }
 
 func isValidRole(role string) bool {
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"koalbot_api/internal/logging"
+	"koalbot_api/internal/model"
+	"koalbot_api/internal/queryspec"
+	"koalbot_api/internal/repository"
+)
+
+const (
+	WebhookSignatureHeader = "X-Koalbot-Signature"
+	WebhookEventHeader     = "X-Koalbot-Event"
+	WebhookDeliveryHeader  = "X-Koalbot-Delivery"
+
+	webhookPollEvery     = 5 * time.Second
+	webhookDispatchLimit = 100
+	webhookClaimLimit    = 20
+	webhookMaxBackoff    = 6 * time.Hour
+	webhookResponseLimit = 2048
+)
+
+var (
+	ErrWebhookNameRequired = errors.New("name_required")
+	ErrInvalidWebhookURL   = errors.New("invalid_url")
+	ErrInvalidWebhookEvent = errors.New("invalid_event")
+
+	errWebhookTargetBlocked = errors.New("webhook target address is not allowed")
+)
+
+type WebhookService struct {
+	repo        *repository.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+}
+
+func NewWebhookService(repo *repository.WebhookRepository, timeout time.Duration, maxAttempts int, retryBase time.Duration) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		client:      newWebhookClient(timeout),
+		maxAttempts: maxAttempts,
+		retryBase:   retryBase,
+	}
+}
+
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || blockedWebhookAddr(addr) {
+				return errWebhookTargetBlocked
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func blockedWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified()
+}
+
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]model.Webhook, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *WebhookService) Get(ctx context.Context, id int64) (model.Webhook, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *WebhookService) Create(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	if err := validateWebhook(&webhook); err != nil {
+		return model.Webhook{}, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return model.Webhook{}, err
+	}
+	webhook.Secret = secret
+	return s.repo.Create(ctx, webhook)
+}
+
+func (s *WebhookService) Update(ctx context.Context, webhook model.Webhook, rotateSecret bool) (model.Webhook, error) {
+	current, err := s.repo.Get(ctx, webhook.ID)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+	if err := validateWebhook(&webhook); err != nil {
+		return model.Webhook{}, err
+	}
+	webhook.Secret = current.Secret
+	if rotateSecret {
+		if webhook.Secret, err = newWebhookSecret(); err != nil {
+			return model.Webhook{}, err
+		}
+	}
+	return s.repo.Update(ctx, webhook)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *WebhookService) Test(ctx context.Context, id int64, sentBy string) (model.WebhookDelivery, error) {
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	payload, err := json.Marshal(map[string]any{
+		"id":         "evt_test",
+		"event":      model.WebhookTest,
+		"created_at": time.Now().UTC(),
+		"data":       map[string]any{"webhook_id": id, "sent_by": sentBy},
+	})
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+	return s.repo.Enqueue(ctx, id, model.WebhookTest, payload)
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, webhookID int64, q queryspec.Query) ([]model.WebhookDelivery, queryspec.Page, error) {
+	return s.repo.ListDeliveries(ctx, webhookID, q)
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID, deliveryID int64) (model.WebhookDelivery, error) {
+	return s.repo.Redeliver(ctx, webhookID, deliveryID)
+}
+
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.process(ctx); err != nil && ctx.Err() == nil {
+				logging.Errorf("webhook: %v", err)
+			}
+		}
+	}
+}
+
+func (s *WebhookService) process(ctx context.Context) error {
+	dispatched, err := s.repo.Dispatch(ctx, webhookDispatchLimit)
+	if err != nil {
+		return fmt.Errorf("dispatch failed: %w", err)
+	}
+	if dispatched > 0 {
+		logging.Infof("webhook: dispatched %d events", dispatched)
+	}
+
+	for {
+		deliveries, targets, err := s.repo.Claim(ctx, webhookClaimLimit, s.client.Timeout+30*time.Second)
+		if err != nil {
+			return fmt.Errorf("claim failed: %w", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func(delivery model.WebhookDelivery, target model.Webhook) {
+				defer wg.Done()
+				if err := s.repo.Record(ctx, s.deliver(ctx, delivery, target)); err != nil && ctx.Err() == nil {
+					logging.Errorf("webhook: record delivery %d failed: %v", delivery.ID, err)
+				}
+			}(deliveries[i], targets[i])
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookClaimLimit || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery model.WebhookDelivery, target model.Webhook) model.WebhookDelivery {
+	started := time.Now()
+	statusCode, response, err := s.send(ctx, delivery, target)
+	duration := int(time.Since(started).Milliseconds())
+
+	delivery.LastDurationMS = &duration
+	delivery.LastResponse = response
+	delivery.LastStatusCode = nil
+	delivery.LastError = ""
+	if statusCode > 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	if err != nil {
+		delivery.LastError = err.Error()
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliverySucceeded
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = model.DeliveryFailed
+	default:
+		delivery.Status = model.DeliveryPending
+		delivery.NextAttemptAt = time.Now().Add(s.backoff(delivery.Attempts))
+	}
+	return delivery
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery model.WebhookDelivery, target model.Webhook) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "koalbot-webhooks/1")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(target.Secret, time.Now().Unix(), delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	response := strings.ToValidUTF8(strings.ReplaceAll(string(body), "\x00", ""), "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, response, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, response, nil
+}
+
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.retryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return delay
+}
+
+func validateWebhook(webhook *model.Webhook) error {
+	webhook.Name = strings.TrimSpace(webhook.Name)
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	if webhook.Name == "" {
+		return ErrWebhookNameRequired
+	}
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	if host := strings.ToLower(parsed.Hostname()); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInvalidWebhookURL
+	}
+	if addr, err := netip.ParseAddr(parsed.Hostname()); err == nil && blockedWebhookAddr(addr) {
+		return ErrInvalidWebhookURL
+	}
+
+	events := make([]string, 0, len(webhook.Events))
+	seen := make(map[string]bool, len(webhook.Events))
+	for _, event := range webhook.Events {
+		event = strings.TrimSpace(event)
+		if !isWebhookEvent(event) {
+			return ErrInvalidWebhookEvent
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	webhook.Events = events
+	return nil
+}
+
+func isWebhookEvent(event string) bool {
+	for _, known := range model.WebhookEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
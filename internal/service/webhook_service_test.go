@@ -0,0 +1,95 @@
+package service
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{
+			secret:    "whsec_test",
+			timestamp: 1700000000,
+			body:      `{"event":"pengguna.expired"}`,
+			want:      "t=1700000000,v1=0cc274ae63c0effd885d711bbd52240fb5c8fa6e42bdde217014e3405bc47e21",
+		},
+		{
+			secret:    "whsec_test",
+			timestamp: 1700000000,
+			want:      "t=1700000000,v1=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc",
+		},
+	}
+	for _, tt := range tests {
+		if got := SignWebhook(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("SignWebhook(%q, %d, %q) = %q, want %q", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+
+	base := SignWebhook("whsec_test", 1700000000, []byte("{}"))
+	for name, other := range map[string]string{
+		"secret":    SignWebhook("whsec_other", 1700000000, []byte("{}")),
+		"timestamp": SignWebhook("whsec_test", 1700000001, []byte("{}")),
+		"body":      SignWebhook("whsec_test", 1700000000, []byte("{ }")),
+	} {
+		if other == base {
+			t.Errorf("changing the %s did not change the signature", name)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	s := &WebhookService{retryBase: 30 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 10, want: 256 * time.Minute},
+		{attempts: 11, want: webhookMaxBackoff},
+		{attempts: 1000, want: webhookMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := s.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestBlockedWebhookAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "127.0.0.1", want: true},
+		{addr: "10.1.2.3", want: true},
+		{addr: "172.16.0.1", want: true},
+		{addr: "192.168.1.10", want: true},
+		{addr: "169.254.169.254", want: true},
+		{addr: "0.0.0.0", want: true},
+		{addr: "224.0.0.1", want: true},
+		{addr: "::1", want: true},
+		{addr: "::", want: true},
+		{addr: "fe80::1", want: true},
+		{addr: "fd00::1", want: true},
+		{addr: "::ffff:127.0.0.1", want: true},
+		{addr: "::ffff:10.0.0.1", want: true},
+		{addr: "8.8.8.8"},
+		{addr: "172.32.0.1"},
+		{addr: "2001:4860:4860::8888"},
+		{addr: "::ffff:8.8.8.8"},
+	}
+	for _, tt := range tests {
+		if got := blockedWebhookAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("blockedWebhookAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
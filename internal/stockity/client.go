@@ -9,7 +9,10 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 var ErrInvalidCredentials = errors.New("invalid_credentials")
@@ -25,18 +28,54 @@ func (e *UpstreamError) Error() string {
 }
 
 type Client struct {
-	baseURL string
+	baseURL atomic.Pointer[string]
+	timeout atomic.Int64
 	http    *http.Client
 }
 
 func NewClient(baseURL string, timeout time.Duration) *Client {
-	trimmed := strings.TrimRight(baseURL, "/")
-	return &Client{
-		baseURL: trimmed,
+	c := &Client{
 		http: &http.Client{
-			Timeout: timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport,
+				otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+					return "stockity " + r.Method + " " + r.URL.Path
+				}),
+			),
 		},
 	}
+	c.SetBaseURL(baseURL)
+	c.SetTimeout(timeout)
+	return c
+}
+
+func (c *Client) BaseURL() string {
+	return *c.baseURL.Load()
+}
+
+func (c *Client) SetBaseURL(baseURL string) {
+	trimmed := strings.TrimRight(baseURL, "/")
+	c.baseURL.Store(&trimmed)
+}
+
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout.Store(int64(timeout))
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, []byte, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), time.Duration(c.timeout.Load()))
+	defer cancel()
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
 }
 
 type SignInResponse struct {
@@ -103,7 +142,7 @@ func (c *Client) SignIn(ctx context.Context, deviceID, deviceType, email, passwo
 		return SignInResponse{}, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/passport/v2/sign_in", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL()+"/passport/v2/sign_in", bytes.NewReader(body))
 	if err != nil {
 		return SignInResponse{}, err
 	}
@@ -111,13 +150,7 @@ func (c *Client) SignIn(ctx context.Context, deviceID, deviceType, email, passwo
 	req.Header.Set("Device-Id", deviceID)
 	req.Header.Set("Device-Type", deviceType)
 
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return SignInResponse{}, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	resp, respBody, err := c.do(req)
 	if err != nil {
 		return SignInResponse{}, err
 	}
@@ -153,7 +186,7 @@ func (c *Client) SignIn(ctx context.Context, deviceID, deviceType, email, passwo
 }
 
 func (c *Client) GetProfile(ctx context.Context, deviceID, deviceType, authToken string) (Profile, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/platform/private/v2/profile", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL()+"/platform/private/v2/profile", nil)
 	if err != nil {
 		return Profile{}, err
 	}
@@ -161,13 +194,7 @@ func (c *Client) GetProfile(ctx context.Context, deviceID, deviceType, authToken
 	req.Header.Set("Device-Type", deviceType)
 	req.Header.Set("Authorization-Token", authToken)
 
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return Profile{}, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	resp, respBody, err := c.do(req)
 	if err != nil {
 		return Profile{}, err
 	}
@@ -196,6 +223,26 @@ func (c *Client) GetProfile(ctx context.Context, deviceID, deviceType, authToken
 	return profile, nil
 }
 
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.BaseURL()+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, _, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 500 {
+		return &UpstreamError{
+			Status:   resp.StatusCode,
+			Endpoint: "ping",
+		}
+	}
+	return nil
+}
+
 func limitBody(body []byte) string {
 	const maxLen = 1024
 	if len(body) == 0 {